@@ -1,9 +1,14 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -22,18 +27,32 @@ func main() {
 	// Configure CORS
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{cfg.FrontendURL},
-		AllowMethods:     []string{"GET"},
+		AllowMethods:     []string{"GET", "POST", "DELETE"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Accept"},
 		AllowCredentials: true,
 	}))
 
 	// Load API routes
-	routes.RegisterRoutes(router)
+	closeJobs, err := routes.RegisterRoutes(router, cfg)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to register routes")
+	}
+
+	// Request contexts derive from baseCtx, so canceling it stops in-flight analyses
+	baseCtx, cancelBase := context.WithCancel(context.Background())
+	defer cancelBase()
+
+	server := &http.Server{
+		Addr:              ":" + cfg.ServerPort,
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return baseCtx },
+	}
 
 	// Graceful shutdown handling
 	go func() {
 		logger.Info().Msgf("Server running on port %s", cfg.ServerPort)
-		if err := router.Run(":" + cfg.ServerPort); err != nil {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logger.Fatal().Err(err).Msg("Failed to start server")
 		}
 	}()
@@ -43,4 +62,15 @@ func main() {
 	<-quit
 
 	logger.Info().Msg("Shutting down server...")
-}
\ No newline at end of file
+	cancelBase()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error().Err(err).Msg("Server forced to shut down")
+	}
+
+	// Cancel queued and running jobs and wait for their workers to exit
+	closeJobs()
+}
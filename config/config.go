@@ -2,14 +2,74 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 // Config holds application configuration
 type Config struct {
-	ServerPort  string
-	FrontendURL string
+	ServerPort      string
+	FrontendURL     string
+	AnalysisTimeout time.Duration
+	ShutdownTimeout time.Duration
+	Fetch           FetchConfig
+	LinkCheck       LinkCheckConfig
+	SSRF            SSRFConfig
+	Jobs            JobsConfig
+}
+
+// FetchConfig holds settings for outbound HTTP requests to analyzed pages
+type FetchConfig struct {
+	Timeout               time.Duration
+	UserAgent             string
+	ProxyURL              string
+	MaxRedirects          int
+	TLSInsecureSkipVerify bool
+	TLSMinVersion         string
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	IdleConnTimeout       time.Duration
+
+	// MaxBodySize is the number of body bytes read before a page is truncated
+	MaxBodySize int
+
+	// ReadTimeout is how long reading the body may stall before the fetch fails
+	ReadTimeout time.Duration
+}
+
+// LinkCheckConfig bounds how hard the link checker may hit target hosts
+type LinkCheckConfig struct {
+	Concurrency        int
+	PerHostConcurrency int
+	PerHostRPS         float64
+	MaxRetries         int
+	RetryBaseDelay     time.Duration
+	RetryMaxDelay      time.Duration
+}
+
+// JobsConfig sizes the in-process queue of asynchronous analysis jobs
+type JobsConfig struct {
+	Workers   int
+	QueueSize int
+
+	// Retention is how long a finished job and its result are kept
+	Retention time.Duration
+
+	// AnalysisTimeout replaces AnalysisTimeout for jobs, which may run longer
+	// than a client is willing to wait for a response
+	AnalysisTimeout time.Duration
+}
+
+// SSRFConfig controls which hosts and addresses the service may connect to
+type SSRFConfig struct {
+	Enabled    bool
+	AllowCIDRs []string
+	DenyCIDRs  []string
+	AllowHosts []string
+	DenyHosts  []string
 }
 
 // LoadConfig loads environment variables from .env file
@@ -20,8 +80,44 @@ func LoadConfig() *Config {
 	}
 
 	return &Config{
-		ServerPort:  getEnv("SERVER_PORT", "8081"),
-		FrontendURL: getEnv("FRONTEND_URL", "http://localhost:3000"),
+		ServerPort:      getEnv("SERVER_PORT", "8081"),
+		FrontendURL:     getEnv("FRONTEND_URL", "http://localhost:3000"),
+		AnalysisTimeout: getEnvDuration("ANALYSIS_TIMEOUT", 60*time.Second),
+		ShutdownTimeout: getEnvDuration("SHUTDOWN_TIMEOUT", 10*time.Second),
+		Fetch: FetchConfig{
+			Timeout:               getEnvDuration("FETCH_TIMEOUT", 15*time.Second),
+			UserAgent:             getEnv("FETCH_USER_AGENT", "web-analyzer-service/1.0"),
+			ProxyURL:              getEnv("FETCH_PROXY_URL", ""),
+			MaxRedirects:          getEnvInt("FETCH_MAX_REDIRECTS", 10),
+			TLSInsecureSkipVerify: getEnvBool("FETCH_TLS_INSECURE_SKIP_VERIFY", false),
+			TLSMinVersion:         getEnv("FETCH_TLS_MIN_VERSION", "1.2"),
+			MaxIdleConns:          getEnvInt("FETCH_MAX_IDLE_CONNS", 100),
+			MaxIdleConnsPerHost:   getEnvInt("FETCH_MAX_IDLE_CONNS_PER_HOST", 10),
+			IdleConnTimeout:       getEnvDuration("FETCH_IDLE_CONN_TIMEOUT", 90*time.Second),
+			MaxBodySize:           getEnvInt("FETCH_MAX_BODY_SIZE", 10<<20),
+			ReadTimeout:           getEnvDuration("FETCH_READ_TIMEOUT", 10*time.Second),
+		},
+		LinkCheck: LinkCheckConfig{
+			Concurrency:        getEnvInt("LINK_CHECK_CONCURRENCY", 20),
+			PerHostConcurrency: getEnvInt("LINK_CHECK_PER_HOST_CONCURRENCY", 4),
+			PerHostRPS:         getEnvFloat("LINK_CHECK_PER_HOST_RPS", 10),
+			MaxRetries:         getEnvInt("LINK_CHECK_MAX_RETRIES", 2),
+			RetryBaseDelay:     getEnvDuration("LINK_CHECK_RETRY_BASE_DELAY", 500*time.Millisecond),
+			RetryMaxDelay:      getEnvDuration("LINK_CHECK_RETRY_MAX_DELAY", 10*time.Second),
+		},
+		SSRF: SSRFConfig{
+			Enabled:    getEnvBool("SSRF_PROTECTION", true),
+			AllowCIDRs: getEnvList("SSRF_ALLOW_CIDRS"),
+			DenyCIDRs:  getEnvList("SSRF_DENY_CIDRS"),
+			AllowHosts: getEnvList("SSRF_ALLOW_HOSTS"),
+			DenyHosts:  getEnvList("SSRF_DENY_HOSTS"),
+		},
+		Jobs: JobsConfig{
+			Workers:         getEnvInt("JOBS_WORKERS", 4),
+			QueueSize:       getEnvInt("JOBS_QUEUE_SIZE", 100),
+			Retention:       getEnvDuration("JOBS_RETENTION", time.Hour),
+			AnalysisTimeout: getEnvDuration("JOBS_ANALYSIS_TIMEOUT", 10*time.Minute),
+		},
 	}
 }
 
@@ -31,4 +127,75 @@ func getEnv(key, fallback string) string {
 		return value
 	}
 	return fallback
-}
\ No newline at end of file
+}
+
+// getEnvList fetches a comma-separated env variable as a list, skipping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvInt fetches an integer env variable with a fallback value
+func getEnvInt(key string, fallback int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		Logger.Warn().Str("key", key).Str("value", value).Msg("Invalid integer env variable, using fallback")
+		return fallback
+	}
+	return parsed
+}
+
+// getEnvFloat fetches a floating point env variable with a fallback value
+func getEnvFloat(key string, fallback float64) float64 {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		Logger.Warn().Str("key", key).Str("value", value).Msg("Invalid float env variable, using fallback")
+		return fallback
+	}
+	return parsed
+}
+
+// getEnvBool fetches a boolean env variable with a fallback value
+func getEnvBool(key string, fallback bool) bool {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		Logger.Warn().Str("key", key).Str("value", value).Msg("Invalid boolean env variable, using fallback")
+		return fallback
+	}
+	return parsed
+}
+
+// getEnvDuration fetches a duration env variable (e.g. "10s") with a fallback value
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		Logger.Warn().Str("key", key).Str("value", value).Msg("Invalid duration env variable, using fallback")
+		return fallback
+	}
+	return parsed
+}
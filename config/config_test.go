@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -44,7 +45,7 @@ func TestLoadConfig_WithFallbackValues(t *testing.T) {
 	config := LoadConfig()
 
 	// Assertions
-	assert.Equal(t, "8081", config.ServerPort)       // default fallback
+	assert.Equal(t, "8081", config.ServerPort)                   // default fallback
 	assert.Equal(t, "http://localhost:3000", config.FrontendURL) // default fallback
 }
 
@@ -69,3 +70,98 @@ func TestGetEnv_WithFallback(t *testing.T) {
 	// Assertions
 	assert.Equal(t, "default_value", value)
 }
+
+func TestLoadConfig_FetchDefaults(t *testing.T) {
+	os.Unsetenv("ANALYSIS_TIMEOUT")
+	os.Unsetenv("FETCH_TIMEOUT")
+	os.Unsetenv("FETCH_MAX_REDIRECTS")
+
+	config := LoadConfig()
+
+	assert.Equal(t, 60*time.Second, config.AnalysisTimeout)
+	assert.Equal(t, 15*time.Second, config.Fetch.Timeout)
+	assert.Equal(t, 10, config.Fetch.MaxRedirects)
+	assert.Equal(t, "1.2", config.Fetch.TLSMinVersion)
+	assert.NotEmpty(t, config.Fetch.UserAgent)
+	assert.Equal(t, 10<<20, config.Fetch.MaxBodySize)
+	assert.Equal(t, 10*time.Second, config.Fetch.ReadTimeout)
+}
+
+func TestLoadConfig_FetchFromEnv(t *testing.T) {
+	os.Setenv("FETCH_TIMEOUT", "3s")
+	os.Setenv("FETCH_USER_AGENT", "test-agent")
+	os.Setenv("FETCH_TLS_INSECURE_SKIP_VERIFY", "true")
+	os.Setenv("FETCH_MAX_BODY_SIZE", "1024")
+	os.Setenv("FETCH_READ_TIMEOUT", "2s")
+	defer os.Unsetenv("FETCH_TIMEOUT")
+	defer os.Unsetenv("FETCH_USER_AGENT")
+	defer os.Unsetenv("FETCH_TLS_INSECURE_SKIP_VERIFY")
+	defer os.Unsetenv("FETCH_MAX_BODY_SIZE")
+	defer os.Unsetenv("FETCH_READ_TIMEOUT")
+
+	config := LoadConfig()
+
+	assert.Equal(t, 3*time.Second, config.Fetch.Timeout)
+	assert.Equal(t, "test-agent", config.Fetch.UserAgent)
+	assert.True(t, config.Fetch.TLSInsecureSkipVerify)
+	assert.Equal(t, 1024, config.Fetch.MaxBodySize)
+	assert.Equal(t, 2*time.Second, config.Fetch.ReadTimeout)
+}
+
+func TestGetEnvInt_InvalidValueUsesFallback(t *testing.T) {
+	os.Setenv("MY_INT", "not-a-number")
+	defer os.Unsetenv("MY_INT")
+
+	assert.Equal(t, 7, getEnvInt("MY_INT", 7))
+}
+
+func TestGetEnvDuration_WithEnvVar(t *testing.T) {
+	os.Setenv("MY_DURATION", "250ms")
+	defer os.Unsetenv("MY_DURATION")
+
+	assert.Equal(t, 250*time.Millisecond, getEnvDuration("MY_DURATION", time.Second))
+}
+
+func TestLoadConfig_LinkCheckFromEnv(t *testing.T) {
+	os.Setenv("LINK_CHECK_CONCURRENCY", "5")
+	os.Setenv("LINK_CHECK_PER_HOST_RPS", "2.5")
+	defer os.Unsetenv("LINK_CHECK_CONCURRENCY")
+	defer os.Unsetenv("LINK_CHECK_PER_HOST_RPS")
+
+	config := LoadConfig()
+
+	assert.Equal(t, 5, config.LinkCheck.Concurrency)
+	assert.Equal(t, 4, config.LinkCheck.PerHostConcurrency)
+	assert.Equal(t, 2.5, config.LinkCheck.PerHostRPS)
+	assert.Equal(t, 2, config.LinkCheck.MaxRetries)
+	assert.Equal(t, 500*time.Millisecond, config.LinkCheck.RetryBaseDelay)
+}
+
+func TestLoadConfig_SSRF(t *testing.T) {
+	os.Unsetenv("SSRF_PROTECTION")
+	os.Setenv("SSRF_ALLOW_CIDRS", "10.1.0.0/16, 192.168.5.4")
+	os.Setenv("SSRF_DENY_HOSTS", "*.corp.example.com,")
+	defer os.Unsetenv("SSRF_ALLOW_CIDRS")
+	defer os.Unsetenv("SSRF_DENY_HOSTS")
+
+	config := LoadConfig()
+
+	assert.True(t, config.SSRF.Enabled, "SSRF protection should be on by default")
+	assert.Equal(t, []string{"10.1.0.0/16", "192.168.5.4"}, config.SSRF.AllowCIDRs)
+	assert.Equal(t, []string{"*.corp.example.com"}, config.SSRF.DenyHosts)
+	assert.Empty(t, config.SSRF.AllowHosts)
+}
+
+func TestLoadConfig_Jobs(t *testing.T) {
+	os.Setenv("JOBS_WORKERS", "8")
+	os.Setenv("JOBS_RETENTION", "30m")
+	defer os.Unsetenv("JOBS_WORKERS")
+	defer os.Unsetenv("JOBS_RETENTION")
+
+	config := LoadConfig()
+
+	assert.Equal(t, 8, config.Jobs.Workers)
+	assert.Equal(t, 100, config.Jobs.QueueSize)
+	assert.Equal(t, 30*time.Minute, config.Jobs.Retention)
+	assert.Equal(t, 10*time.Minute, config.Jobs.AnalysisTimeout)
+}
@@ -24,4 +24,4 @@ func init() {
 
 	// Set global log level
 	zerolog.SetGlobalLevel(zerolog.InfoLevel)
-}
\ No newline at end of file
+}
@@ -0,0 +1,579 @@
+package fetcher
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/uikee/web-analyzer-service/config"
+	"github.com/uikee/web-analyzer-service/internal/validators"
+)
+
+// Request describes a single outbound HTTP request
+type Request struct {
+	Method string
+	URL    string
+	Header http.Header
+
+	// Timeout overrides the fetcher's default per-request timeout when set
+	Timeout time.Duration
+
+	// DiscardBody skips reading the response body, e.g. for link checks
+	DiscardBody bool
+
+	// AcceptTypes lists the media types a successful response may have, e.g.
+	// "text/html". Other types fail with ErrUnsupportedContentType before the
+	// body is read. Empty accepts anything, as does a missing Content-Type.
+	AcceptTypes []string
+}
+
+// Response holds the parts of a fetched page needed for validation and analysis
+type Response struct {
+	URL        string
+	StatusCode int
+	Proto      string
+	Header     http.Header
+	Body       []byte
+
+	// Compression is the content coding the body was sent with, e.g. "gzip".
+	// The body itself is always decoded.
+	Compression string
+
+	// Hops lists every request made while following redirects, in order.
+	// The last hop is the one that produced this response.
+	Hops []Hop
+
+	// Truncated is set when the body was longer than the maximum body size;
+	// Body then holds only its beginning
+	Truncated bool
+}
+
+// Hop is a single request/response exchange in a redirect chain
+type Hop struct {
+	URL        string
+	StatusCode int
+
+	// Location is the raw Location header of a redirect response
+	Location string
+
+	// Duration is the time until the response headers arrived
+	Duration time.Duration
+}
+
+// Fetcher performs outbound HTTP requests on behalf of the service and the link checker
+type Fetcher interface {
+	Fetch(ctx context.Context, req Request) (*Response, error)
+}
+
+// FetchFunc adapts an ordinary function to the Fetcher interface
+type FetchFunc func(ctx context.Context, req Request) (*Response, error)
+
+// Fetch calls f(ctx, req)
+func (f FetchFunc) Fetch(ctx context.Context, req Request) (*Response, error) {
+	return f(ctx, req)
+}
+
+var (
+	// ErrRequestFailed indicates that the HTTP request could not be completed
+	ErrRequestFailed = errors.New("request failed")
+
+	// ErrReadBody indicates that the response body could not be read
+	ErrReadBody = errors.New("failed to read response body")
+
+	// ErrTooManyRedirects indicates that the redirect limit was exceeded
+	ErrTooManyRedirects = errors.New("too many redirects")
+
+	// ErrRedirectLoop indicates that the redirect limit was exceeded while revisiting the same URLs
+	ErrRedirectLoop = errors.New("redirect loop")
+
+	// ErrReadTimeout indicates that reading the response body stalled for longer than the read timeout
+	ErrReadTimeout = errors.New("timed out reading response body")
+
+	// ErrUnsupportedContentType indicates that the response has a media type the request does not accept.
+	// The error returned by Fetch is a *ContentTypeError.
+	ErrUnsupportedContentType = errors.New("unsupported content type")
+
+	// ErrInvalidProxyURL indicates that the configured proxy URL could not be parsed
+	ErrInvalidProxyURL = errors.New("invalid proxy URL")
+
+	// ErrInvalidTLSVersion indicates that the configured minimum TLS version is unknown
+	ErrInvalidTLSVersion = errors.New("invalid minimum TLS version")
+)
+
+// ContentTypeError describes a successful response rejected because of its media type
+type ContentTypeError struct {
+	ContentType string
+
+	// ContentLength is the declared body size, or -1 if unknown
+	ContentLength int64
+}
+
+// Error describes the rejected content type
+func (e *ContentTypeError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrUnsupportedContentType, e.ContentType)
+}
+
+// Unwrap returns ErrUnsupportedContentType
+func (e *ContentTypeError) Unwrap() error {
+	return ErrUnsupportedContentType
+}
+
+// RedirectError describes a redirect chain that was given up on, because it was too
+// long or looped. Err is ErrTooManyRedirects, possibly joined with ErrRedirectLoop.
+type RedirectError struct {
+	// Hops lists every request made before giving up; the last one is a redirect
+	Hops []Hop
+	Err  error
+}
+
+// Error describes why the chain was given up on
+func (e *RedirectError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the reason the chain was given up on
+func (e *RedirectError) Unwrap() error {
+	return e.Err
+}
+
+const (
+	defaultTimeout      = 15 * time.Second
+	defaultMaxRedirects = 10
+	defaultUserAgent    = "web-analyzer-service/1.0"
+	discardDrainBytes   = 4 << 10
+	defaultMaxBodySize  = 10 << 20
+	defaultReadTimeout  = 10 * time.Second
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// HTTPFetcher is the default Fetcher backed by a pooled http.Client
+type HTTPFetcher struct {
+	client      *http.Client
+	timeout     time.Duration
+	userAgent   string
+	policy      *validators.NetworkPolicy
+	maxBodySize int
+	readTimeout time.Duration
+}
+
+// NewHTTPFetcher creates an HTTPFetcher configured from the given settings.
+// Zero values fall back to sensible defaults. When policy is non-nil every
+// request, redirect hop and dialed address must pass it; nil disables the checks.
+func NewHTTPFetcher(cfg config.FetchConfig, policy *validators.NetworkPolicy) (*HTTPFetcher, error) {
+	proxy := http.ProxyFromEnvironment
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidProxyURL, cfg.ProxyURL)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.TLSInsecureSkipVerify, // #nosec G402 -- opt-in via configuration
+	}
+	if cfg.TLSMinVersion != "" {
+		version, ok := tlsVersions[cfg.TLSMinVersion]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidTLSVersion, cfg.TLSMinVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+	dial := dialer.DialContext
+	if policy != nil {
+		dial = guardedDial(policy.DialContext(dialer.DialContext), dialer.DialContext)
+	}
+
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dial,
+		TLSClientConfig:       tlsConfig,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	var next http.RoundTripper = transport
+	if policy != nil {
+		transport.Proxy = proxyFromContext
+		next = &proxyTransport{next: transport, proxy: proxy, policy: policy}
+	}
+
+	maxRedirects := cfg.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	userAgent := cfg.UserAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+
+	maxBodySize := cfg.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = defaultMaxBodySize
+	}
+
+	readTimeout := cfg.ReadTimeout
+	if readTimeout <= 0 {
+		readTimeout = defaultReadTimeout
+	}
+
+	return &HTTPFetcher{
+		client: &http.Client{
+			Transport: &recordingTransport{next: next},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					if revisits(req, via) {
+						return fmt.Errorf("%w: %w", ErrTooManyRedirects, ErrRedirectLoop)
+					}
+					return ErrTooManyRedirects
+				}
+				if policy != nil {
+					return policy.CheckHost(req.URL.Hostname())
+				}
+				return nil
+			},
+		},
+		timeout:     timeout,
+		userAgent:   userAgent,
+		policy:      policy,
+		maxBodySize: maxBodySize,
+		readTimeout: readTimeout,
+	}, nil
+}
+
+// revisits reports whether req targets a URL already requested earlier in the chain
+func revisits(req *http.Request, via []*http.Request) bool {
+	target := req.URL.String()
+	for _, previous := range via {
+		if previous.URL.String() == target {
+			return true
+		}
+	}
+	return false
+}
+
+// hopRecorderKey is the context key under which Fetch stores its hopRecorder
+type hopRecorderKey struct{}
+
+// hopRecorder collects the hops of a single Fetch call. The client follows
+// redirects sequentially, so no locking is needed.
+type hopRecorder struct {
+	hops []Hop
+}
+
+// recordingTransport times every round trip and records it in the request's hopRecorder
+type recordingTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip performs the request through the wrapped transport
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if recorder, ok := req.Context().Value(hopRecorderKey{}).(*hopRecorder); ok && err == nil {
+		recorder.hops = append(recorder.hops, Hop{
+			URL:        req.URL.String(),
+			StatusCode: resp.StatusCode,
+			Location:   resp.Header.Get("Location"),
+			Duration:   time.Since(start),
+		})
+	}
+	return resp, err
+}
+
+// proxyKey is the context key under which proxyTransport stores the proxy chosen for a request
+type proxyKey struct{}
+
+// proxyTransport chooses the proxy of each request itself, so that the network
+// policy can be applied to targets the transport never dials, and so that the
+// dialer knows which address is the proxy
+type proxyTransport struct {
+	next   http.RoundTripper
+	proxy  func(*http.Request) (*url.URL, error)
+	policy *validators.NetworkPolicy
+}
+
+// RoundTrip checks the target of a proxied request against the policy, as the proxy
+// connects to it in our place, and passes the chosen proxy on in the request context.
+// Redirect hops are separate round trips and are checked the same way.
+func (t *proxyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	proxyURL, err := t.proxy(req)
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	if _, err := t.policy.ResolveAllowed(req.Context(), req.URL.Hostname()); err != nil {
+		return nil, err
+	}
+	return t.next.RoundTrip(req.WithContext(context.WithValue(req.Context(), proxyKey{}, proxyURL)))
+}
+
+// proxyFromContext returns the proxy proxyTransport chose for the request, if any
+func proxyFromContext(req *http.Request) (*url.URL, error) {
+	proxyURL, _ := req.Context().Value(proxyKey{}).(*url.URL)
+	return proxyURL, nil
+}
+
+// guardedDial dials the proxy chosen for the request with direct and every other
+// address with guarded. Only the exact proxy address is exempt, so a target sharing
+// the proxy's host, or a request that bypasses the proxy, is still checked.
+func guardedDial(guarded, direct func(ctx context.Context, network, address string) (net.Conn, error)) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		if proxyURL, ok := ctx.Value(proxyKey{}).(*url.URL); ok && address == proxyAddr(proxyURL) {
+			return direct(ctx, network, address)
+		}
+		return guarded(ctx, network, address)
+	}
+}
+
+// proxyAddr returns the host:port the transport dials to reach a proxy
+func proxyAddr(proxyURL *url.URL) string {
+	port := proxyURL.Port()
+	if port == "" {
+		switch proxyURL.Scheme {
+		case "https":
+			port = "443"
+		case "socks5", "socks5h":
+			port = "1080"
+		default:
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(proxyURL.Hostname(), port)
+}
+
+// Fetch performs the request and reads the response body within the timeout.
+// At most the configured maximum body size is read; longer bodies are truncated.
+func (f *HTTPFetcher) Fetch(ctx context.Context, req Request) (*Response, error) {
+	timeout := f.timeout
+	if req.Timeout > 0 {
+		timeout = req.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Aborts the request when the body stalls; see readBody
+	ctx, abort := context.WithCancelCause(ctx)
+	defer abort(nil)
+
+	recorder := &hopRecorder{}
+	ctx = context.WithValue(ctx, hopRecorderKey{}, recorder)
+
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, req.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRequestFailed, err)
+	}
+	if f.policy != nil {
+		if err := f.policy.CheckHost(httpReq.URL.Hostname()); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrRequestFailed, err)
+		}
+	}
+	for key, values := range req.Header {
+		httpReq.Header[key] = values
+	}
+	if httpReq.Header.Get("User-Agent") == "" {
+		httpReq.Header.Set("User-Agent", f.userAgent)
+	}
+
+	resp, err := f.client.Do(httpReq)
+	if err != nil {
+		config.Logger.Error().Err(err).Str("url", req.URL).Msg("Failed to fetch URL")
+		if errors.Is(err, ErrTooManyRedirects) {
+			err = &RedirectError{Hops: recorder.hops, Err: err}
+		}
+		return nil, fmt.Errorf("%w: %w", ErrRequestFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if req.DiscardBody {
+		// Drain a little so the connection can be reused for small bodies
+		_, _ = io.CopyN(io.Discard, resp.Body, discardDrainBytes)
+		return &Response{
+			URL:         resp.Request.URL.String(),
+			StatusCode:  resp.StatusCode,
+			Proto:       resp.Proto,
+			Header:      resp.Header,
+			Compression: compression(resp),
+			Hops:        recorder.hops,
+		}, nil
+	}
+
+	if err := checkContentType(resp, req.AcceptTypes); err != nil {
+		config.Logger.Warn().Err(err).Str("url", req.URL).Msg("Rejected response content type")
+		return nil, err
+	}
+
+	body, truncated, err := f.readBody(resp.Body, abort)
+	if err != nil {
+		if errors.Is(context.Cause(ctx), ErrReadTimeout) {
+			err = ErrReadTimeout
+		}
+		config.Logger.Error().Err(err).Str("url", req.URL).Msg("Failed to read response body")
+		return nil, fmt.Errorf("%w: %w", ErrReadBody, err)
+	}
+	if truncated {
+		config.Logger.Warn().Str("url", req.URL).Int("max_body_size", f.maxBodySize).Msg("Response body truncated")
+	}
+
+	return &Response{
+		URL:         resp.Request.URL.String(),
+		StatusCode:  resp.StatusCode,
+		Proto:       resp.Proto,
+		Header:      resp.Header,
+		Body:        body,
+		Compression: compression(resp),
+		Hops:        recorder.hops,
+		Truncated:   truncated,
+	}, nil
+}
+
+// checkContentType fails successful responses whose media type is not one of accept
+func checkContentType(resp *http.Response, accept []string) error {
+	contentType := resp.Header.Get("Content-Type")
+	if len(accept) == 0 || contentType == "" || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil && slices.Contains(accept, mediaType) {
+		return nil
+	}
+	return &ContentTypeError{ContentType: contentType, ContentLength: resp.ContentLength}
+}
+
+// readBody reads up to maxBodySize bytes of body and reports whether there was more.
+// If no data arrives for readTimeout, abort cancels the request with ErrReadTimeout.
+func (f *HTTPFetcher) readBody(body io.Reader, abort context.CancelCauseFunc) ([]byte, bool, error) {
+	stall := time.AfterFunc(f.readTimeout, func() { abort(ErrReadTimeout) })
+	defer stall.Stop()
+
+	// Read one byte past the limit to tell a body of exactly maxBodySize from a longer one
+	limited := io.LimitReader(body, int64(f.maxBodySize)+1)
+	data, err := io.ReadAll(&progressReader{r: limited, progress: func() { stall.Reset(f.readTimeout) }})
+	if err != nil {
+		return nil, false, err
+	}
+	if len(data) > f.maxBodySize {
+		return data[:f.maxBodySize], true, nil
+	}
+	return data, false, nil
+}
+
+// progressReader calls progress after every read that returned data
+type progressReader struct {
+	r        io.Reader
+	progress func()
+}
+
+// Read reads from the wrapped reader
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.progress()
+	}
+	return n, err
+}
+
+// compression reports the content coding of a response. The transport strips
+// Content-Encoding when it transparently decodes gzip, so that case is checked first.
+func compression(resp *http.Response) string {
+	if resp.Uncompressed {
+		return "gzip"
+	}
+	return strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+}
+
+// Error kinds reported by ClassifyError
+const (
+	ErrorKindTimeout          = "timeout"
+	ErrorKindCanceled         = "canceled"
+	ErrorKindDNS              = "dns"
+	ErrorKindConnection       = "connection"
+	ErrorKindTLS              = "tls"
+	ErrorKindTooManyRedirects = "too_many_redirects"
+	ErrorKindRedirectLoop     = "redirect_loop"
+	ErrorKindReadBody         = "read_body"
+	ErrorKindNetwork          = "network"
+	ErrorKindBlocked          = "blocked"
+)
+
+// ClassifyError maps a Fetch error to a short, stable error kind
+func ClassifyError(err error) string {
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, validators.ErrDisallowedHost), errors.Is(err, validators.ErrDisallowedAddress):
+		return ErrorKindBlocked
+	case errors.Is(err, validators.ErrHostResolution):
+		return ErrorKindDNS
+	case errors.Is(err, ErrRedirectLoop):
+		return ErrorKindRedirectLoop
+	case errors.Is(err, ErrTooManyRedirects):
+		return ErrorKindTooManyRedirects
+	case errors.Is(err, context.Canceled):
+		return ErrorKindCanceled
+	case errors.Is(err, context.DeadlineExceeded), isTimeout(err):
+		return ErrorKindTimeout
+	case errors.As(err, &dnsErr):
+		return ErrorKindDNS
+	case errors.As(err, &certErr), errors.As(err, &recordErr):
+		return ErrorKindTLS
+	case errors.Is(err, ErrReadTimeout):
+		return ErrorKindTimeout
+	case errors.Is(err, ErrReadBody):
+		return ErrorKindReadBody
+	case errors.As(err, &opErr):
+		return ErrorKindConnection
+	default:
+		return ErrorKindNetwork
+	}
+}
+
+// isTimeout reports whether err is a network timeout
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,418 @@
+package fetcher
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uikee/web-analyzer-service/config"
+	"github.com/uikee/web-analyzer-service/internal/validators"
+)
+
+func newTestFetcher(t *testing.T, cfg config.FetchConfig) *HTTPFetcher {
+	f, err := NewHTTPFetcher(cfg, nil)
+	require.NoError(t, err)
+	return f
+}
+
+func TestFetch_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html></html>"))
+	}))
+	defer server.Close()
+
+	resp, err := newTestFetcher(t, config.FetchConfig{}).Fetch(context.Background(), Request{URL: server.URL})
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/html", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "<html></html>", string(resp.Body))
+}
+
+func TestFetch_Non200IsNotAnError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	resp, err := newTestFetcher(t, config.FetchConfig{}).Fetch(context.Background(), Request{URL: server.URL})
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestFetch_RecordsFinalURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	resp, err := newTestFetcher(t, config.FetchConfig{}).Fetch(context.Background(), Request{URL: server.URL + "/old"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/new", resp.URL)
+	require.Len(t, resp.Hops, 2)
+	assert.Equal(t, Hop{URL: server.URL + "/old", StatusCode: http.StatusMovedPermanently, Location: "/new", Duration: resp.Hops[0].Duration}, resp.Hops[0])
+	assert.Equal(t, server.URL+"/new", resp.Hops[1].URL)
+	assert.Equal(t, http.StatusOK, resp.Hops[1].StatusCode)
+	assert.Empty(t, resp.Hops[1].Location)
+}
+
+func TestFetch_MaxRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Path+"x", http.StatusFound)
+	}))
+	defer server.Close()
+
+	_, err := newTestFetcher(t, config.FetchConfig{MaxRedirects: 2}).Fetch(context.Background(), Request{URL: server.URL + "/"})
+
+	assert.ErrorIs(t, err, ErrRequestFailed)
+	assert.ErrorIs(t, err, ErrTooManyRedirects)
+	assert.NotErrorIs(t, err, ErrRedirectLoop)
+}
+
+func TestFetch_RedirectLoop(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/a" {
+			http.Redirect(w, r, "/b", http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, "/a", http.StatusFound)
+	}))
+	defer server.Close()
+
+	_, err := newTestFetcher(t, config.FetchConfig{MaxRedirects: 3}).Fetch(context.Background(), Request{URL: server.URL + "/a"})
+
+	assert.ErrorIs(t, err, ErrTooManyRedirects)
+	assert.ErrorIs(t, err, ErrRedirectLoop)
+	assert.Equal(t, ErrorKindRedirectLoop, ClassifyError(err))
+
+	// The hops made before giving up are kept
+	var redirectErr *RedirectError
+	require.ErrorAs(t, err, &redirectErr)
+	require.Len(t, redirectErr.Hops, 3)
+	assert.Equal(t, server.URL+"/a", redirectErr.Hops[0].URL)
+	assert.Equal(t, "/b", redirectErr.Hops[0].Location)
+	assert.Equal(t, server.URL+"/b", redirectErr.Hops[1].URL)
+	assert.Equal(t, server.URL+"/a", redirectErr.Hops[2].URL)
+}
+
+func TestFetch_UserAgent(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+	}))
+	defer server.Close()
+
+	_, err := newTestFetcher(t, config.FetchConfig{UserAgent: "test-agent"}).Fetch(context.Background(), Request{URL: server.URL})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "test-agent", userAgent)
+}
+
+func TestFetch_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(t, config.FetchConfig{Timeout: time.Minute})
+	_, err := f.Fetch(context.Background(), Request{URL: server.URL, Timeout: 20 * time.Millisecond})
+
+	assert.ErrorIs(t, err, ErrRequestFailed)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestFetch_RequestFailed(t *testing.T) {
+	_, err := newTestFetcher(t, config.FetchConfig{}).Fetch(context.Background(), Request{URL: "http://invalid-url"})
+
+	assert.ErrorIs(t, err, ErrRequestFailed)
+}
+
+func TestFetch_ReadBodyFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1") // Simulate a mismatch to cause an error
+	}))
+	defer server.Close()
+
+	_, err := newTestFetcher(t, config.FetchConfig{}).Fetch(context.Background(), Request{URL: server.URL})
+
+	assert.ErrorIs(t, err, ErrReadBody)
+}
+
+func TestNewHTTPFetcher_InvalidSettings(t *testing.T) {
+	_, err := NewHTTPFetcher(config.FetchConfig{ProxyURL: "::not a url"}, nil)
+	assert.ErrorIs(t, err, ErrInvalidProxyURL)
+
+	_, err = NewHTTPFetcher(config.FetchConfig{TLSMinVersion: "0.9"}, nil)
+	assert.ErrorIs(t, err, ErrInvalidTLSVersion)
+}
+
+func TestClassifyError(t *testing.T) {
+	f := newTestFetcher(t, config.FetchConfig{})
+
+	_, err := f.Fetch(context.Background(), Request{URL: "http://invalid-url.invalid"})
+	assert.Equal(t, ErrorKindDNS, ClassifyError(err))
+
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closed.Close()
+	_, err = f.Fetch(context.Background(), Request{URL: closed.URL})
+	assert.Equal(t, ErrorKindConnection, ClassifyError(err))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = f.Fetch(ctx, Request{URL: "http://example.com"})
+	assert.Equal(t, ErrorKindCanceled, ClassifyError(err))
+
+	assert.Equal(t, ErrorKindTooManyRedirects, ClassifyError(ErrTooManyRedirects))
+	assert.Equal(t, "", ClassifyError(nil))
+}
+
+func TestFetch_DiscardBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html></html>"))
+	}))
+	defer server.Close()
+
+	resp, err := newTestFetcher(t, config.FetchConfig{}).Fetch(context.Background(), Request{URL: server.URL, DiscardBody: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Body)
+}
+
+func newPolicy(t *testing.T, cfg config.SSRFConfig) *validators.NetworkPolicy {
+	policy, err := validators.NewNetworkPolicy(cfg)
+	require.NoError(t, err)
+	return policy
+}
+
+func TestFetch_NetworkPolicyBlocksInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("A blocked address must not be contacted")
+	}))
+	defer server.Close()
+
+	f, err := NewHTTPFetcher(config.FetchConfig{}, newPolicy(t, config.SSRFConfig{}))
+	require.NoError(t, err)
+
+	_, err = f.Fetch(context.Background(), Request{URL: server.URL})
+
+	assert.ErrorIs(t, err, ErrRequestFailed)
+	assert.ErrorIs(t, err, validators.ErrDisallowedAddress)
+	assert.Equal(t, ErrorKindBlocked, ClassifyError(err))
+}
+
+func TestFetch_NetworkPolicyAllowCIDR(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	f, err := NewHTTPFetcher(config.FetchConfig{}, newPolicy(t, config.SSRFConfig{AllowCIDRs: []string{"127.0.0.1"}}))
+	require.NoError(t, err)
+
+	resp, err := f.Fetch(context.Background(), Request{URL: server.URL})
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestFetch_NetworkPolicyChecksRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://localhost/admin", http.StatusFound)
+	}))
+	defer server.Close()
+
+	f, err := NewHTTPFetcher(config.FetchConfig{}, newPolicy(t, config.SSRFConfig{AllowCIDRs: []string{"127.0.0.1"}}))
+	require.NoError(t, err)
+
+	_, err = f.Fetch(context.Background(), Request{URL: server.URL})
+
+	assert.ErrorIs(t, err, validators.ErrDisallowedHost)
+}
+
+// stubResolver answers lookups from a fixed host-to-address table
+type stubResolver map[string]string
+
+func (r stubResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	addr, ok := r[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return []net.IPAddr{{IP: net.ParseIP(addr)}}, nil
+}
+
+func TestFetch_NetworkPolicyAllowsConfiguredProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	defer proxy.Close()
+
+	policy := newPolicy(t, config.SSRFConfig{}).WithResolver(stubResolver{"example.com": "93.184.215.14"})
+	f, err := NewHTTPFetcher(config.FetchConfig{ProxyURL: proxy.URL}, policy)
+	require.NoError(t, err)
+
+	resp, err := f.Fetch(context.Background(), Request{URL: "http://example.com/page"})
+
+	assert.NoError(t, err, "Dialing the proxy itself is not subject to the policy")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "http://example.com/page", proxied)
+}
+
+func TestFetch_NetworkPolicyChecksProxiedTargets(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+		}
+	}))
+	defer proxy.Close()
+
+	policy := newPolicy(t, config.SSRFConfig{}).WithResolver(stubResolver{
+		"example.com":  "93.184.215.14",
+		"internal.lan": "10.0.0.8",
+	})
+	f, err := NewHTTPFetcher(config.FetchConfig{ProxyURL: proxy.URL}, policy)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{name: "IP literal", url: "http://169.254.169.254/latest/meta-data/"},
+		{name: "Host resolving to an internal address", url: "http://internal.lan/"},
+		{name: "Redirect hop", url: "http://example.com/redirect"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := f.Fetch(context.Background(), Request{Method: http.MethodHead, URL: tt.url})
+
+			assert.ErrorIs(t, err, validators.ErrDisallowedAddress)
+			assert.Equal(t, ErrorKindBlocked, ClassifyError(err))
+		})
+	}
+	assert.Equal(t, []string{"http://example.com/redirect"}, proxied, "Blocked targets must not reach the proxy")
+}
+
+func TestGuardedDial_ExemptsOnlyTheProxyAddress(t *testing.T) {
+	errGuarded, errDirect := errors.New("guarded"), errors.New("direct")
+	dial := guardedDial(
+		func(ctx context.Context, network, address string) (net.Conn, error) { return nil, errGuarded },
+		func(ctx context.Context, network, address string) (net.Conn, error) { return nil, errDirect },
+	)
+	proxied := context.WithValue(context.Background(), proxyKey{}, &url.URL{Scheme: "http", Host: "10.0.0.5:3128"})
+
+	_, err := dial(proxied, "tcp", "10.0.0.5:3128")
+	assert.ErrorIs(t, err, errDirect, "The proxy itself is dialed directly")
+
+	_, err = dial(proxied, "tcp", "10.0.0.5:6379")
+	assert.ErrorIs(t, err, errGuarded, "Another port on the proxy host is a target like any other")
+
+	_, err = dial(context.Background(), "tcp", "10.0.0.5:3128")
+	assert.ErrorIs(t, err, errGuarded, "Requests that bypass the proxy are always checked")
+}
+
+func TestProxyAddr(t *testing.T) {
+	assert.Equal(t, "proxy.internal:80", proxyAddr(&url.URL{Scheme: "http", Host: "proxy.internal"}))
+	assert.Equal(t, "proxy.internal:443", proxyAddr(&url.URL{Scheme: "https", Host: "proxy.internal"}))
+	assert.Equal(t, "10.0.0.5:3128", proxyAddr(&url.URL{Scheme: "http", Host: "10.0.0.5:3128"}))
+}
+
+func TestFetch_ReportsProtocolAndCompression(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		_, _ = gz.Write([]byte("<html></html>"))
+		_ = gz.Close()
+	}))
+	defer server.Close()
+
+	resp, err := newTestFetcher(t, config.FetchConfig{}).Fetch(context.Background(), Request{URL: server.URL})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "HTTP/1.1", resp.Proto)
+	assert.Equal(t, "gzip", resp.Compression)
+	assert.Equal(t, "<html></html>", string(resp.Body), "The body should be decoded")
+}
+
+func TestFetch_TruncatesLargeBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("a", 100)))
+	}))
+	defer server.Close()
+
+	resp, err := newTestFetcher(t, config.FetchConfig{MaxBodySize: 10}).Fetch(context.Background(), Request{URL: server.URL})
+
+	assert.NoError(t, err)
+	assert.True(t, resp.Truncated)
+	assert.Equal(t, strings.Repeat("a", 10), string(resp.Body))
+
+	resp, err = newTestFetcher(t, config.FetchConfig{MaxBodySize: 100}).Fetch(context.Background(), Request{URL: server.URL})
+
+	assert.NoError(t, err)
+	assert.False(t, resp.Truncated, "A body of exactly the maximum size is complete")
+	assert.Len(t, resp.Body, 100)
+}
+
+func TestFetch_ReadTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Trickle the body, then stall
+		for i := 0; i < 3; i++ {
+			_, _ = w.Write([]byte("<p>"))
+			w.(http.Flusher).Flush()
+			time.Sleep(20 * time.Millisecond)
+		}
+		time.Sleep(300 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(t, config.FetchConfig{ReadTimeout: 50 * time.Millisecond})
+	_, err := f.Fetch(context.Background(), Request{URL: server.URL})
+
+	assert.ErrorIs(t, err, ErrReadBody)
+	assert.ErrorIs(t, err, ErrReadTimeout)
+	assert.Equal(t, ErrorKindTimeout, ClassifyError(err))
+}
+
+func TestFetch_AcceptTypes(t *testing.T) {
+	contentType := ""
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(t, config.FetchConfig{})
+	fetch := func(ct string, code int) error {
+		contentType, status = ct, code
+		_, err := f.Fetch(context.Background(), Request{URL: server.URL, AcceptTypes: []string{"text/html"}})
+		return err
+	}
+
+	assert.NoError(t, fetch("text/html; charset=utf-8", http.StatusOK))
+	assert.NoError(t, fetch("TEXT/HTML", http.StatusOK), "Media types are case-insensitive")
+	err := fetch("application/pdf", http.StatusOK)
+	assert.ErrorIs(t, err, ErrUnsupportedContentType)
+	var typeErr *ContentTypeError
+	if assert.ErrorAs(t, err, &typeErr) {
+		assert.Equal(t, &ContentTypeError{ContentType: "application/pdf", ContentLength: 0}, typeErr)
+	}
+	assert.ErrorIs(t, fetch("not a media type;;", http.StatusOK), ErrUnsupportedContentType)
+	assert.NoError(t, fetch("application/json", http.StatusNotFound), "Error responses are not checked")
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uikee/web-analyzer-service/internal/services"
+	"github.com/uikee/web-analyzer-service/internal/validators"
+)
+
+// maxHTMLSize is the largest document, or multipart upload, accepted by POST /api/v1/analyze/html
+const maxHTMLSize = 10 << 20
+
+// multipartMemory is how much of a multipart upload is held in memory before spilling to disk
+const multipartMemory = 1 << 20
+
+// Names of the document in a raw and in a multipart request, as reported in field errors
+const (
+	fieldBody = "body"
+	fieldFile = "file"
+)
+
+var (
+	// ErrUnsupportedMediaType indicates that the request body is neither an HTML document nor a multipart upload
+	ErrUnsupportedMediaType = errors.New("request body must be text/html, application/xhtml+xml or multipart/form-data")
+
+	// ErrMalformedUpload indicates that a multipart upload could not be read
+	ErrMalformedUpload = errors.New("malformed multipart upload")
+
+	// ErrMissingDocument indicates that no HTML document was supplied
+	ErrMissingDocument = errors.New("HTML document is required")
+)
+
+// htmlRequest is a supplied document together with the parameters sent alongside it
+type htmlRequest struct {
+	input  services.HTMLInput
+	params url.Values
+
+	// documentField is where the document was expected: "body" or "file"
+	documentField string
+}
+
+// readHTMLRequest reads the document from the raw body, with its parameters in
+// the query string, or from the "file" part of a multipart upload, with its
+// parameters in form fields or the query string
+func readHTMLRequest(c *gin.Context) (htmlRequest, error) {
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxHTMLSize)
+
+	mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if err != nil {
+		return htmlRequest{}, ErrUnsupportedMediaType
+	}
+
+	switch mediaType {
+	case services.MediaTypeHTML, services.MediaTypeXHTML:
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return htmlRequest{}, readError(err, ErrMalformedBody)
+		}
+		return htmlRequest{
+			input:         services.HTMLInput{Body: data, ContentType: c.GetHeader("Content-Type")},
+			params:        c.Request.URL.Query(),
+			documentField: fieldBody,
+		}, nil
+
+	case "multipart/form-data":
+		c.Request.Body = body
+		if err := c.Request.ParseMultipartForm(multipartMemory); err != nil {
+			return htmlRequest{}, readError(err, ErrMalformedUpload)
+		}
+		req := htmlRequest{params: c.Request.Form, documentField: fieldFile}
+
+		file, header, err := c.Request.FormFile(fieldFile)
+		if errors.Is(err, http.ErrMissingFile) {
+			return req, nil
+		}
+		if err != nil {
+			return htmlRequest{}, ErrMalformedUpload
+		}
+		defer file.Close()
+
+		if req.input.Body, err = io.ReadAll(file); err != nil {
+			return htmlRequest{}, ErrMalformedUpload
+		}
+		// Generic uploads carry no useful type, so the service sniffs them
+		if contentType := header.Header.Get("Content-Type"); contentType != "application/octet-stream" {
+			req.input.ContentType = contentType
+		}
+		return req, nil
+
+	default:
+		return htmlRequest{}, ErrUnsupportedMediaType
+	}
+}
+
+// readError reports a body that exceeded maxHTMLSize as too large and any other read failure as fallback
+func readError(err, fallback error) error {
+	var sizeErr *http.MaxBytesError
+	if errors.As(err, &sizeErr) {
+		return ErrRequestTooLarge
+	}
+	return fallback
+}
+
+// validate checks the document and its parameters and returns one error per invalid field.
+// Only the syntax of base_url is checked: nothing is fetched from it, and link checks
+// still go through the fetcher's network policy.
+func (r htmlRequest) validate() []FieldError {
+	var fields []FieldError
+	if len(r.input.Body) == 0 {
+		fields = append(fields, FieldError{Field: r.documentField, Message: ErrMissingDocument.Error()})
+	}
+	if baseURL := r.params.Get("base_url"); baseURL != "" {
+		if _, err := validators.ParseHTTPURL(baseURL); err != nil {
+			fields = append(fields, FieldError{Field: "base_url", Message: err.Error()})
+		}
+	}
+	return fields
+}
+
+// options returns the analyzer selection sent with the document
+func (r htmlRequest) options() services.AnalyzeOptions {
+	return services.AnalyzeOptions{
+		Checks: splitList(r.params["checks"]),
+		Skip:   splitList(r.params["skip"]),
+	}
+}
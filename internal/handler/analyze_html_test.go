@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/uikee/web-analyzer-service/internal/services"
+)
+
+const samplePage = `<html><head><title>Preview</title></head><body><a href="/pricing">Pricing</a></body></html>`
+
+// newHTMLRouter serves handler.AnalyzeHTML the way RegisterRoutes does
+func newHTMLRouter(handler *AnalyzerHandler) *gin.Engine {
+	r := gin.Default()
+	r.POST("/api/v1/analyze/html", handler.AnalyzeHTML)
+	return r
+}
+
+func performBodyRequest(r http.Handler, path, contentType string, body []byte) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// multipartBody builds a multipart form with the given fields and, unless fileType is empty, a file part
+func multipartBody(t *testing.T, fields map[string]string, fileType, content string) (string, []byte) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for name, value := range fields {
+		require.NoError(t, writer.WriteField(name, value))
+	}
+	if fileType != "" {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="file"; filename="page.html"`)
+		header.Set("Content-Type", fileType)
+		part, err := writer.CreatePart(header)
+		require.NoError(t, err)
+		_, err = part.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	return writer.FormDataContentType(), buf.Bytes()
+}
+
+func TestAnalyzeHTML_RawBody(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Create handler
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
+
+	// Define mock behavior
+	input := services.HTMLInput{Body: []byte(samplePage), ContentType: "text/html; charset=utf-8", BaseURL: "https://staging.internal/"}
+	mockAnalyzerService.On("AnalyzeHTML", mock.Anything, input, services.AnalyzeOptions{Skip: []string{"links"}}).
+		Return(services.AnalysisResult{Title: "Preview"}, nil)
+
+	// Test case: Document in the body, parameters in the query string; the base URL
+	// is never fetched, so a host that does not resolve publicly is fine
+	w := performBodyRequest(newHTMLRouter(handler), "/api/v1/analyze/html?base_url=https://staging.internal/&skip=links", "text/html; charset=utf-8", []byte(samplePage))
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"title":"Preview"`)
+	mockAnalyzerService.AssertExpectations(t)
+	mockValidator.AssertNotCalled(t, "Validate", mock.Anything)
+}
+
+func TestAnalyzeHTML_MultipartUpload(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Create handler
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
+
+	// Define mock behavior
+	input := services.HTMLInput{Body: []byte(samplePage), BaseURL: "https://cms.example.com/preview"}
+	mockAnalyzerService.On("AnalyzeHTML", mock.Anything, input, services.AnalyzeOptions{Checks: []string{"title", "headings"}}).
+		Return(services.AnalysisResult{Title: "Preview"}, nil)
+
+	// Test case: Generic upload type is left for the service to sniff
+	contentType, body := multipartBody(t, map[string]string{"base_url": "https://cms.example.com/preview", "checks": "title,headings"}, "application/octet-stream", samplePage)
+	w := performBodyRequest(newHTMLRouter(handler), "/api/v1/analyze/html", contentType, body)
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockAnalyzerService.AssertExpectations(t)
+}
+
+func TestAnalyzeHTML_MultipartKeepsFileType(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Create handler
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
+
+	// Define mock behavior
+	input := services.HTMLInput{Body: []byte(samplePage), ContentType: "application/xhtml+xml"}
+	mockAnalyzerService.On("AnalyzeHTML", mock.Anything, input, services.AnalyzeOptions{}).Return(services.AnalysisResult{}, nil)
+
+	// Test case: Upload without a base URL
+	contentType, body := multipartBody(t, nil, "application/xhtml+xml", samplePage)
+	w := performBodyRequest(newHTMLRouter(handler), "/api/v1/analyze/html", contentType, body)
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockAnalyzerService.AssertExpectations(t)
+	mockValidator.AssertNotCalled(t, "Validate", mock.Anything)
+}
+
+func TestAnalyzeHTML_FieldErrors(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Create handler
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
+
+	// Test case: Upload without a file and with an invalid base URL
+	contentType, body := multipartBody(t, map[string]string{"base_url": "ftp://example.com"}, "", "")
+	w := performBodyRequest(newHTMLRouter(handler), "/api/v1/analyze/html", contentType, body)
+
+	// Assertions
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"status": 400,
+		"error": "invalid request",
+		"fields": [
+			{"field": "file", "error": "HTML document is required"},
+			{"field": "base_url", "error": "unsupported URL scheme, only http and https are allowed"}
+		]
+	}`, w.Body.String())
+	mockAnalyzerService.AssertNotCalled(t, "AnalyzeHTML", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAnalyzeHTML_EmptyBody(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Create handler
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
+
+	// Test case: Raw request without a document
+	w := performBodyRequest(newHTMLRouter(handler), "/api/v1/analyze/html", "text/html", nil)
+
+	// Assertions
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `{"field":"body","error":"HTML document is required"}`)
+}
+
+func TestAnalyzeHTML_UnsupportedMediaType(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Create handler
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
+
+	// Test case: JSON sent to the HTML endpoint
+	w := performBodyRequest(newHTMLRouter(handler), "/api/v1/analyze/html", "application/json", []byte(`{"url": "http://example.com"}`))
+
+	// Assertions
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	assert.Contains(t, w.Body.String(), ErrUnsupportedMediaType.Error())
+}
+
+func TestAnalyzeHTML_TooLarge(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Create handler
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
+
+	// Test case: Raw document and upload over the limit
+	large := "<html>" + strings.Repeat("a", maxHTMLSize) + "</html>"
+	raw := performBodyRequest(newHTMLRouter(handler), "/api/v1/analyze/html", "text/html", []byte(large))
+	contentType, body := multipartBody(t, nil, "text/html", large)
+	upload := performBodyRequest(newHTMLRouter(handler), "/api/v1/analyze/html", contentType, body)
+
+	// Assertions
+	assert.Equal(t, http.StatusRequestEntityTooLarge, raw.Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, upload.Code)
+}
+
+func TestAnalyzeHTML_ResponseAnalyzerRequested(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Create handler
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
+
+	// Define mock behavior
+	input := services.HTMLInput{Body: []byte(samplePage), ContentType: "text/html"}
+	mockAnalyzerService.On("AnalyzeHTML", mock.Anything, input, services.AnalyzeOptions{Checks: []string{"security_headers"}}).
+		Return(services.AnalysisResult{}, fmt.Errorf("%w: security_headers", services.ErrAnalyzerNeedsFetch))
+
+	// Test case: Analyzer that describes the HTTP response
+	w := performBodyRequest(newHTMLRouter(handler), "/api/v1/analyze/html?checks=security_headers", "text/html", []byte(samplePage))
+
+	// Assertions
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "analyzer requires a fetched page: security_headers")
+}
@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uikee/web-analyzer-service/internal/services"
+	"github.com/uikee/web-analyzer-service/internal/utils"
+	"github.com/uikee/web-analyzer-service/internal/validators"
+	"golang.org/x/net/http/httpguts"
+)
+
+// Bounds of the numeric options of an AnalyzeRequest
+const (
+	maxTimeoutMs          = 10 * 60 * 1000
+	maxLinkCheckTimeoutMs = 60 * 1000
+	maxLinkCheckRetries   = 10
+)
+
+// maxRequestBodySize is the largest JSON body accepted by POST /api/v1/analyze
+const maxRequestBodySize = 1 << 20
+
+// managedHeaders are set by the service or have their own field and cannot be
+// passed in headers
+var managedHeaders = map[string]string{
+	"Host":              "is set from url",
+	"Content-Length":    "is managed by the service",
+	"Transfer-Encoding": "is managed by the service",
+	"Connection":        "is managed by the service",
+	"Accept-Encoding":   "is managed by the service",
+	"Cookie":            "use cookies instead",
+	"User-Agent":        "use user_agent instead",
+}
+
+var (
+	// ErrInvalidRequest indicates that one or more fields of the request body are invalid
+	ErrInvalidRequest = errors.New("invalid request")
+
+	// ErrMalformedBody indicates that the request body is not a JSON object
+	ErrMalformedBody = errors.New("request body must be a JSON object")
+
+	// ErrRequestTooLarge indicates that the request body exceeds maxRequestBodySize
+	ErrRequestTooLarge = errors.New("request body is too large")
+)
+
+// AnalyzeRequest is the JSON body of POST /api/v1/analyze. Every field except
+// URL is optional; omitted fields keep the configured behavior.
+type AnalyzeRequest struct {
+	URL    string   `json:"url"`
+	Checks []string `json:"checks"`
+	Skip   []string `json:"skip"`
+
+	// TimeoutMs bounds the whole analysis; it cannot exceed the configured timeout
+	TimeoutMs      int `json:"timeout_ms"`
+	FetchTimeoutMs int `json:"fetch_timeout_ms"`
+
+	LinkCheck LinkCheckRequest `json:"link_check"`
+
+	// Headers, Cookies and UserAgent are sent with the page request only
+	Headers   map[string]string `json:"headers"`
+	Cookies   map[string]string `json:"cookies"`
+	UserAgent string            `json:"user_agent"`
+}
+
+// LinkCheckRequest tunes link checking for a single analysis
+type LinkCheckRequest struct {
+	TimeoutMs  int  `json:"timeout_ms"`
+	MaxRetries *int `json:"max_retries"`
+}
+
+// FieldError describes why a single field of a request was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"error"`
+}
+
+// decodeAnalyzeRequest reads an AnalyzeRequest from body. Unknown fields and
+// values of the wrong type are reported as field errors.
+func decodeAnalyzeRequest(body io.Reader) (AnalyzeRequest, []FieldError, error) {
+	var req AnalyzeRequest
+
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&req)
+
+	var typeErr *json.UnmarshalTypeError
+	var sizeErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &sizeErr):
+		return req, nil, ErrRequestTooLarge
+	case err == nil:
+		if decoder.More() {
+			return req, nil, ErrMalformedBody
+		}
+		return req, nil, nil
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return req, []FieldError{{Field: typeErr.Field, Message: "must be of type " + typeErr.Type.String()}}, nil
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return req, []FieldError{{Field: field, Message: "unknown field"}}, nil
+	default:
+		return req, nil, ErrMalformedBody
+	}
+}
+
+// bindAnalyzeRequest reads and validates the AnalyzeRequest in the body of c. If it
+// is not valid, the error response is written and ok is false.
+func bindAnalyzeRequest(c *gin.Context, validator validators.URLValidator, analyzers []string) (req AnalyzeRequest, ok bool) {
+	req, fields, err := decodeAnalyzeRequest(http.MaxBytesReader(c.Writer, c.Request.Body, maxRequestBodySize))
+	if errors.Is(err, ErrRequestTooLarge) {
+		handleError(c, http.StatusRequestEntityTooLarge, err, "Request body too large")
+		return req, false
+	}
+	if err != nil {
+		handleError(c, http.StatusBadRequest, err, "Malformed request body")
+		return req, false
+	}
+
+	if fields == nil {
+		fields = req.validate(validator, analyzers)
+	}
+	if len(fields) > 0 {
+		handleFieldErrors(c, fields)
+		return req, false
+	}
+	return req, true
+}
+
+// validate checks every field of the request and returns one error per invalid field.
+// Names in checks and skip must be among analyzers.
+func (r AnalyzeRequest) validate(validator validators.URLValidator, analyzers []string) []FieldError {
+	var fields []FieldError
+	add := func(field, message string) {
+		fields = append(fields, FieldError{Field: field, Message: message})
+	}
+
+	if r.URL == "" {
+		add("url", validators.ErrMissingURL.Error())
+	} else if err := validator.Validate(r.URL); err != nil {
+		add("url", err.Error())
+	}
+
+	checkNames := func(field string, entries []string) {
+		for i, entry := range entries {
+			switch {
+			case strings.TrimSpace(entry) == "":
+				add(fmt.Sprintf("%s[%d]", field, i), "must not be empty")
+			default:
+				for _, name := range splitList([]string{entry}) {
+					if !slices.Contains(analyzers, name) {
+						add(fmt.Sprintf("%s[%d]", field, i), fmt.Sprintf("%s: %s", services.ErrUnknownAnalyzer, name))
+					}
+				}
+			}
+		}
+	}
+	checkNames("checks", r.Checks)
+	checkNames("skip", r.Skip)
+
+	checkRange := func(field string, value, maximum int) {
+		if value < 0 || value > maximum {
+			add(field, fmt.Sprintf("must be between 0 and %d", maximum))
+		}
+	}
+	checkRange("timeout_ms", r.TimeoutMs, maxTimeoutMs)
+	checkRange("fetch_timeout_ms", r.FetchTimeoutMs, maxTimeoutMs)
+	checkRange("link_check.timeout_ms", r.LinkCheck.TimeoutMs, maxLinkCheckTimeoutMs)
+	if r.LinkCheck.MaxRetries != nil {
+		checkRange("link_check.max_retries", *r.LinkCheck.MaxRetries, maxLinkCheckRetries)
+	}
+
+	for _, name := range sortedKeys(r.Headers) {
+		field := "headers." + name
+		switch {
+		case !httpguts.ValidHeaderFieldName(name):
+			add(field, "invalid header name")
+		case managedHeaders[http.CanonicalHeaderKey(name)] != "":
+			add(field, managedHeaders[http.CanonicalHeaderKey(name)])
+		case !httpguts.ValidHeaderFieldValue(r.Headers[name]):
+			add(field, "invalid header value")
+		}
+	}
+
+	for _, name := range sortedKeys(r.Cookies) {
+		if err := (&http.Cookie{Name: name, Value: r.Cookies[name]}).Valid(); err != nil {
+			add("cookies."+name, "invalid cookie")
+		}
+	}
+
+	if !httpguts.ValidHeaderFieldValue(r.UserAgent) {
+		add("user_agent", "invalid header value")
+	}
+
+	return fields
+}
+
+// options converts a validated request into analysis options
+func (r AnalyzeRequest) options() services.AnalyzeOptions {
+	opts := services.AnalyzeOptions{
+		Checks:       splitList(r.Checks),
+		Skip:         splitList(r.Skip),
+		Timeout:      time.Duration(r.TimeoutMs) * time.Millisecond,
+		FetchTimeout: time.Duration(r.FetchTimeoutMs) * time.Millisecond,
+		LinkCheck: utils.LinkCheckOptions{
+			Timeout:    time.Duration(r.LinkCheck.TimeoutMs) * time.Millisecond,
+			MaxRetries: r.LinkCheck.MaxRetries,
+		},
+	}
+
+	header := make(http.Header)
+	for name, value := range r.Headers {
+		header.Set(name, value)
+	}
+	if len(r.Cookies) > 0 {
+		cookies := make([]string, 0, len(r.Cookies))
+		for _, name := range sortedKeys(r.Cookies) {
+			cookies = append(cookies, (&http.Cookie{Name: name, Value: r.Cookies[name]}).String())
+		}
+		header.Set("Cookie", strings.Join(cookies, "; "))
+	}
+	if r.UserAgent != "" {
+		header.Set("User-Agent", r.UserAgent)
+	}
+	if len(header) > 0 {
+		opts.Header = header
+	}
+	return opts
+}
+
+// sortedKeys returns the keys of m in ascending order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uikee/web-analyzer-service/internal/validators"
+)
+
+func TestDecodeAnalyzeRequest(t *testing.T) {
+	body := `{
+		"url": "https://example.com",
+		"checks": ["title", "links"],
+		"timeout_ms": 5000,
+		"link_check": {"timeout_ms": 2000, "max_retries": 0},
+		"headers": {"Accept-Language": "de"},
+		"cookies": {"session": "abc"},
+		"user_agent": "custom-agent/2.0"
+	}`
+
+	req, fields, err := decodeAnalyzeRequest(strings.NewReader(body))
+
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+	assert.Equal(t, "https://example.com", req.URL)
+	assert.Equal(t, []string{"title", "links"}, req.Checks)
+	assert.Equal(t, 5000, req.TimeoutMs)
+	require.NotNil(t, req.LinkCheck.MaxRetries)
+	assert.Equal(t, 0, *req.LinkCheck.MaxRetries)
+}
+
+func TestDecodeAnalyzeRequest_FieldErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		field FieldError
+	}{
+		{"unknown field", `{"url": "https://example.com", "depth": 2}`, FieldError{Field: "depth", Message: "unknown field"}},
+		{"wrong type", `{"url": "https://example.com", "timeout_ms": "5s"}`, FieldError{Field: "timeout_ms", Message: "must be of type int"}},
+		{"nested wrong type", `{"link_check": {"max_retries": true}}`, FieldError{Field: "link_check.max_retries", Message: "must be of type int"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, fields, err := decodeAnalyzeRequest(strings.NewReader(tt.body))
+
+			require.NoError(t, err)
+			assert.Equal(t, []FieldError{tt.field}, fields)
+		})
+	}
+}
+
+func TestDecodeAnalyzeRequest_Malformed(t *testing.T) {
+	for _, body := range []string{``, `not json`, `["https://example.com"]`, `{"url": "https://example.com"} {}`} {
+		_, _, err := decodeAnalyzeRequest(strings.NewReader(body))
+		assert.ErrorIs(t, err, ErrMalformedBody, body)
+	}
+}
+
+func TestAnalyzeRequest_Validate(t *testing.T) {
+	validator := new(MockURLValidator)
+	validator.On("Validate", "ftp://example.com").Return(validators.ErrUnsupportedScheme)
+	retries := 11
+
+	req := AnalyzeRequest{
+		URL:       "ftp://example.com",
+		Checks:    []string{"title", " ", "headings,nope"},
+		Skip:      []string{"links", "lnks"},
+		TimeoutMs: -1,
+		LinkCheck: LinkCheckRequest{TimeoutMs: 120000, MaxRetries: &retries},
+		Headers: map[string]string{
+			"Bad Name":    "x",
+			"Cookie":      "session=abc",
+			"X-Multiline": "a\nb",
+			"X-Trace":     "ok",
+		},
+		Cookies:   map[string]string{"in valid": "x"},
+		UserAgent: "agent\r\n",
+	}
+
+	fields := req.validate(validator, testAnalyzers)
+
+	assert.Equal(t, []FieldError{
+		{Field: "url", Message: validators.ErrUnsupportedScheme.Error()},
+		{Field: "checks[1]", Message: "must not be empty"},
+		{Field: "checks[2]", Message: "unknown analyzer: nope"},
+		{Field: "skip[1]", Message: "unknown analyzer: lnks"},
+		{Field: "timeout_ms", Message: "must be between 0 and 600000"},
+		{Field: "link_check.timeout_ms", Message: "must be between 0 and 60000"},
+		{Field: "link_check.max_retries", Message: "must be between 0 and 10"},
+		{Field: "headers.Bad Name", Message: "invalid header name"},
+		{Field: "headers.Cookie", Message: "use cookies instead"},
+		{Field: "headers.X-Multiline", Message: "invalid header value"},
+		{Field: "cookies.in valid", Message: "invalid cookie"},
+		{Field: "user_agent", Message: "invalid header value"},
+	}, fields)
+}
+
+func TestAnalyzeRequest_ValidateMissingURL(t *testing.T) {
+	fields := AnalyzeRequest{}.validate(new(MockURLValidator), testAnalyzers)
+
+	assert.Equal(t, []FieldError{{Field: "url", Message: validators.ErrMissingURL.Error()}}, fields)
+}
+
+func TestAnalyzeRequest_Options(t *testing.T) {
+	retries := 1
+	req := AnalyzeRequest{
+		URL:            "https://example.com",
+		Checks:         []string{"title,headings"},
+		Skip:           []string{"links"},
+		TimeoutMs:      5000,
+		FetchTimeoutMs: 3000,
+		LinkCheck:      LinkCheckRequest{TimeoutMs: 2000, MaxRetries: &retries},
+		Headers:        map[string]string{"accept-language": "de"},
+		Cookies:        map[string]string{"session": "abc", "theme": "dark"},
+		UserAgent:      "custom-agent/2.0",
+	}
+
+	opts := req.options()
+
+	assert.Equal(t, []string{"title", "headings"}, opts.Checks)
+	assert.Equal(t, []string{"links"}, opts.Skip)
+	assert.Equal(t, 5*time.Second, opts.Timeout)
+	assert.Equal(t, 3*time.Second, opts.FetchTimeout)
+	assert.Equal(t, 2*time.Second, opts.LinkCheck.Timeout)
+	assert.Equal(t, &retries, opts.LinkCheck.MaxRetries)
+	assert.Equal(t, "de", opts.Header.Get("Accept-Language"))
+	assert.Equal(t, "session=abc; theme=dark", opts.Header.Get("Cookie"))
+	assert.Equal(t, "custom-agent/2.0", opts.Header.Get("User-Agent"))
+}
+
+func TestAnalyzeRequest_OptionsWithoutHeaders(t *testing.T) {
+	opts := AnalyzeRequest{URL: "https://example.com"}.options()
+
+	assert.Nil(t, opts.Header)
+}
@@ -1,7 +1,10 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/uikee/web-analyzer-service/config"
@@ -9,25 +12,29 @@ import (
 	"github.com/uikee/web-analyzer-service/internal/validators"
 )
 
-// ErrorResponse represents a standardized error response with HTTP status
+// ErrorResponse represents a standardized error response with HTTP status.
+// Fields lists the rejected fields of an invalid request body.
 type ErrorResponse struct {
-	HTTPStatus int    `json:"status"`
-	Message    string `json:"error"`
+	HTTPStatus int          `json:"status"`
+	Message    string       `json:"error"`
+	Fields     []FieldError `json:"fields,omitempty"`
 }
 
 // AnalyzerHandler provides HTTP handlers for web analysis
 type AnalyzerHandler struct {
 	analyzerService services.AnalyzerService
 	validator       validators.URLValidator
+	analyzers       []string
 }
 
-// NewAnalyzerHandler creates a new instance of AnalyzerHandler
-func NewAnalyzerHandler(service services.AnalyzerService, validator validators.URLValidator) *AnalyzerHandler {
-	return &AnalyzerHandler{analyzerService: service, validator: validator}
+// NewAnalyzerHandler creates a new instance of AnalyzerHandler. Analyzers are the
+// names the service knows, against which checks and skip are validated.
+func NewAnalyzerHandler(service services.AnalyzerService, validator validators.URLValidator, analyzers []string) *AnalyzerHandler {
+	return &AnalyzerHandler{analyzerService: service, validator: validator, analyzers: analyzers}
 }
 
 // handleError sends an appropriate JSON error response and logs it
-func (h *AnalyzerHandler) handleError(c *gin.Context, statusCode int, err error, context string) {
+func handleError(c *gin.Context, statusCode int, err error, context string) {
 	config.Logger.Error().
 		Err(err).
 		Int("status", statusCode).
@@ -40,30 +47,134 @@ func (h *AnalyzerHandler) handleError(c *gin.Context, statusCode int, err error,
 	})
 }
 
-// AnalyzePage handles requests for analyzing web pages
+// handleFieldErrors rejects a request body with one error per invalid field
+func handleFieldErrors(c *gin.Context, fields []FieldError) {
+	config.Logger.Error().
+		Int("status", http.StatusBadRequest).
+		Interface("fields", fields).
+		Msg("Invalid request body")
+
+	c.JSON(http.StatusBadRequest, ErrorResponse{
+		HTTPStatus: http.StatusBadRequest,
+		Message:    ErrInvalidRequest.Error(),
+		Fields:     fields,
+	})
+}
+
+// AnalyzePage handles GET requests for analyzing web pages, a shortcut for
+// AnalyzePageJSON that only takes the URL and the analyzer selection
 func (h *AnalyzerHandler) AnalyzePage(c *gin.Context) {
 	urlParam := c.Query("url")
 	if urlParam == "" {
-		h.handleError(c, http.StatusBadRequest, validators.ErrMissingURL, "Missing URL parameter")
+		handleError(c, http.StatusBadRequest, validators.ErrMissingURL, "Missing URL parameter")
 		return
 	}
 
 	// Validate URL
 	if err := h.validator.Validate(urlParam); err != nil {
-		h.handleError(c, http.StatusBadRequest, err, "Invalid URL format")
+		handleError(c, http.StatusBadRequest, err, "Invalid URL format")
+		return
+	}
+
+	// Optional analyzer selection, e.g. checks=title,headings&skip=links
+	opts := services.AnalyzeOptions{
+		Checks: splitList(c.QueryArray("checks")),
+		Skip:   splitList(c.QueryArray("skip")),
+	}
+
+	h.analyze(c, urlParam, opts)
+}
+
+// AnalyzePageJSON handles POST requests whose JSON body carries the URL and the
+// analysis options. Invalid fields are reported together, each with its reason.
+func (h *AnalyzerHandler) AnalyzePageJSON(c *gin.Context) {
+	req, ok := bindAnalyzeRequest(c, h.validator, h.analyzers)
+	if !ok {
 		return
 	}
 
-	config.Logger.Info().Str("url", urlParam).Msg("Start analyzing web page")
+	h.analyze(c, req.URL, req.options())
+}
+
+// AnalyzeHTML handles POST requests carrying the HTML document to analyze, either
+// as the raw body or as the "file" part of a multipart upload. The optional
+// base_url parameter is where the document is treated as served from.
+func (h *AnalyzerHandler) AnalyzeHTML(c *gin.Context) {
+	req, err := readHTMLRequest(c)
+	switch {
+	case errors.Is(err, ErrRequestTooLarge):
+		handleError(c, http.StatusRequestEntityTooLarge, err, "Request body too large")
+		return
+	case errors.Is(err, ErrUnsupportedMediaType):
+		handleError(c, http.StatusUnsupportedMediaType, err, "Unsupported request body")
+		return
+	case err != nil:
+		handleError(c, http.StatusBadRequest, err, "Malformed request body")
+		return
+	}
 
-	// Perform the web page analysis
-	result, err := h.analyzerService.Analyze(urlParam)
+	if fields := req.validate(); len(fields) > 0 {
+		handleFieldErrors(c, fields)
+		return
+	}
+
+	opts := req.options()
+	req.input.BaseURL = req.params.Get("base_url")
+	h.respond(c, req.input.BaseURL, opts, func(ctx context.Context) (services.AnalysisResult, error) {
+		return h.analyzerService.AnalyzeHTML(ctx, req.input, opts)
+	})
+}
+
+// analyze runs the analysis of a page for a validated request and writes the result
+func (h *AnalyzerHandler) analyze(c *gin.Context, urlParam string, opts services.AnalyzeOptions) {
+	h.respond(c, urlParam, opts, func(ctx context.Context) (services.AnalysisResult, error) {
+		return h.analyzerService.Analyze(ctx, urlParam, opts)
+	})
+}
+
+// respond performs an analysis and writes its result or error. urlParam is only logged.
+func (h *AnalyzerHandler) respond(c *gin.Context, urlParam string, opts services.AnalyzeOptions, run func(ctx context.Context) (services.AnalysisResult, error)) {
+	config.Logger.Info().Str("url", urlParam).Strs("checks", opts.Checks).Strs("skip", opts.Skip).Msg("Start analyzing web page")
+
+	// Perform the web page analysis; it stops if the client disconnects
+	result, err := run(c.Request.Context())
 	if err != nil {
-		h.handleError(c, http.StatusInternalServerError, err, "Error during page analysis")
+		handleError(c, analysisErrorStatus(err), err, "Error during page analysis")
 		return
 	}
 
 	config.Logger.Info().Str("url", urlParam).Msg("Web page analysis completed successfully")
 
 	c.JSON(http.StatusOK, result)
-}
\ No newline at end of file
+}
+
+// analysisErrorStatus maps service errors to HTTP status codes. An unreachable
+// target or a non-200 page is reported as a client error, as the validator did
+// before the fetch moved into the service.
+func analysisErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrUnknownAnalyzer), errors.Is(err, services.ErrAnalyzerNeedsFetch), errors.Is(err, services.ErrTargetNotAllowed):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrFetchFailed), errors.Is(err, services.ErrNon200StatusCode):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrAnalysisTimeout):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, services.ErrAnalysisCanceled):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// splitList flattens repeated and comma-separated query values into a list of names
+func splitList(values []string) []string {
+	var names []string
+	for _, value := range values {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
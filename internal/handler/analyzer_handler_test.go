@@ -1,10 +1,14 @@
 package handler
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -17,8 +21,13 @@ type MockAnalyzerService struct {
 	mock.Mock
 }
 
-func (m *MockAnalyzerService) Analyze(url string) (services.AnalysisResult, error) {
-	args := m.Called(url)
+func (m *MockAnalyzerService) Analyze(ctx context.Context, url string, opts services.AnalyzeOptions) (services.AnalysisResult, error) {
+	args := m.Called(ctx, url, opts)
+	return args.Get(0).(services.AnalysisResult), args.Error(1)
+}
+
+func (m *MockAnalyzerService) AnalyzeHTML(ctx context.Context, input services.HTMLInput, opts services.AnalyzeOptions) (services.AnalysisResult, error) {
+	args := m.Called(ctx, input, opts)
 	return args.Get(0).(services.AnalysisResult), args.Error(1)
 }
 
@@ -32,6 +41,9 @@ func (m *MockURLValidator) Validate(url string) error {
 	return args.Error(0)
 }
 
+// testAnalyzers are the analyzer names the handlers under test accept in checks and skip
+var testAnalyzers = []string{services.AnalyzerTitle, services.AnalyzerHeadings, services.AnalyzerLinks, services.AnalyzerSecurity}
+
 func TestAnalyzePage_Success(t *testing.T) {
 	// Create a gin context
 	gin.SetMode(gin.TestMode)
@@ -42,11 +54,11 @@ func TestAnalyzePage_Success(t *testing.T) {
 	mockValidator := new(MockURLValidator)
 
 	// Create handler
-	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator)
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
 
 	// Define mock behavior
 	mockValidator.On("Validate", "http://example.com").Return(nil)
-	mockAnalyzerService.On("Analyze", "http://example.com").Return(services.AnalysisResult{}, nil)
+	mockAnalyzerService.On("Analyze", mock.Anything, "http://example.com", services.AnalyzeOptions{}).Return(services.AnalysisResult{}, nil)
 
 	// Test case: Valid URL
 	r.GET("/analyze", handler.AnalyzePage)
@@ -66,7 +78,7 @@ func TestAnalyzePage_MissingURL(t *testing.T) {
 	mockValidator := new(MockURLValidator)
 
 	// Create handler
-	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator)
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
 
 	// Test case: Missing URL
 	r := gin.Default()
@@ -84,7 +96,7 @@ func TestAnalyzePage_InvalidURL(t *testing.T) {
 	mockValidator := new(MockURLValidator)
 
 	// Create handler
-	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator)
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
 
 	// Define mock behavior
 	mockValidator.On("Validate", "invalid-url").Return(errors.New("Invalid URL"))
@@ -105,11 +117,11 @@ func TestAnalyzePage_AnalyzerError(t *testing.T) {
 	mockValidator := new(MockURLValidator)
 
 	// Create handler
-	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator)
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
 
 	// Define mock behavior
 	mockValidator.On("Validate", "http://example.com").Return(nil)
-	mockAnalyzerService.On("Analyze", "http://example.com").Return(services.AnalysisResult{}, errors.New("Error during page analysis"))
+	mockAnalyzerService.On("Analyze", mock.Anything, "http://example.com", services.AnalyzeOptions{}).Return(services.AnalysisResult{}, errors.New("Error during page analysis"))
 
 	// Test case: Error during analysis
 	r := gin.Default()
@@ -121,9 +133,313 @@ func TestAnalyzePage_AnalyzerError(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "Error during page analysis")
 }
 
+func TestAnalyzePage_TargetNotReachable(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Create handler
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
+
+	// Define mock behavior
+	mockValidator.On("Validate", "http://example.com").Return(nil)
+	mockAnalyzerService.On("Analyze", mock.Anything, "http://example.com", services.AnalyzeOptions{}).Return(services.AnalysisResult{}, services.ErrFetchFailed)
+
+	// Test case: Target could not be fetched
+	r := gin.Default()
+	r.GET("/analyze", handler.AnalyzePage)
+	w := performRequest(r, "GET", "/analyze?url=http://example.com")
+
+	// Assertions
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), services.ErrFetchFailed.Error())
+}
+
+func TestAnalyzePage_AnalysisTimeout(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Create handler
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
+
+	// Define mock behavior
+	mockValidator.On("Validate", "http://example.com").Return(nil)
+	mockAnalyzerService.On("Analyze", mock.Anything, "http://example.com", services.AnalyzeOptions{}).Return(services.AnalysisResult{}, services.ErrAnalysisTimeout)
+
+	// Test case: Analysis exceeded its deadline
+	r := gin.Default()
+	r.GET("/analyze", handler.AnalyzePage)
+	w := performRequest(r, "GET", "/analyze?url=http://example.com")
+
+	// Assertions
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+}
+
+func TestAnalyzePage_PassesRequestContext(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Create handler
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
+
+	// Define mock behavior
+	type ctxKey struct{}
+	mockValidator.On("Validate", "http://example.com").Return(nil)
+	mockAnalyzerService.On("Analyze", mock.MatchedBy(func(ctx context.Context) bool {
+		return ctx.Value(ctxKey{}) == "request"
+	}), "http://example.com", services.AnalyzeOptions{}).Return(services.AnalysisResult{}, nil)
+
+	// Test case: The request context reaches the service
+	r := gin.Default()
+	r.GET("/analyze", handler.AnalyzePage)
+	req, _ := http.NewRequestWithContext(context.WithValue(context.Background(), ctxKey{}, "request"), "GET", "/analyze?url=http://example.com", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockAnalyzerService.AssertExpectations(t)
+}
+
+func TestAnalyzePage_AnalyzerSelection(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Create handler
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
+
+	// Define mock behavior
+	expected := services.AnalyzeOptions{Checks: []string{"title", "headings", "links"}, Skip: []string{"links"}}
+	mockValidator.On("Validate", "http://example.com").Return(nil)
+	mockAnalyzerService.On("Analyze", mock.Anything, "http://example.com", expected).Return(services.AnalysisResult{}, nil)
+
+	// Test case: Comma-separated and repeated parameters are combined
+	r := gin.Default()
+	r.GET("/analyze", handler.AnalyzePage)
+	w := performRequest(r, "GET", "/analyze?url=http://example.com&checks=title,%20headings&checks=links&skip=links")
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockAnalyzerService.AssertExpectations(t)
+}
+
+func TestAnalyzePage_UnknownAnalyzer(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Create handler
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
+
+	// Define mock behavior
+	mockValidator.On("Validate", "http://example.com").Return(nil)
+	mockAnalyzerService.On("Analyze", mock.Anything, "http://example.com", services.AnalyzeOptions{Checks: []string{"nope"}}).
+		Return(services.AnalysisResult{}, fmt.Errorf("%w: nope", services.ErrUnknownAnalyzer))
+
+	// Test case: Unknown analyzer name
+	r := gin.Default()
+	r.GET("/analyze", handler.AnalyzePage)
+	w := performRequest(r, "GET", "/analyze?url=http://example.com&checks=nope")
+
+	// Assertions
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "unknown analyzer: nope")
+}
+
 func performRequest(r http.Handler, method, path string) *httptest.ResponseRecorder {
 	req, _ := http.NewRequest(method, path, nil)
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 	return w
 }
+
+func performJSONRequest(r http.Handler, path, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAnalyzePage_TargetNotAllowed(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Create handler
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
+
+	// Define mock behavior
+	mockValidator.On("Validate", "http://example.com").Return(nil)
+	mockAnalyzerService.On("Analyze", mock.Anything, "http://example.com", services.AnalyzeOptions{}).Return(services.AnalysisResult{}, services.ErrTargetNotAllowed)
+
+	// Test case: The page redirected to an internal address
+	r := gin.Default()
+	r.GET("/analyze", handler.AnalyzePage)
+	w := performRequest(r, "GET", "/analyze?url=http://example.com")
+
+	// Assertions
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), services.ErrTargetNotAllowed.Error())
+}
+
+func TestAnalyzePageJSON_Success(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Create handler
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
+
+	// Define mock behavior
+	expected := services.AnalyzeOptions{
+		Skip:    []string{"links"},
+		Timeout: 5 * time.Second,
+		Header:  http.Header{"User-Agent": []string{"custom-agent/2.0"}, "Cookie": []string{"session=abc"}},
+	}
+	mockValidator.On("Validate", "http://example.com").Return(nil)
+	mockAnalyzerService.On("Analyze", mock.Anything, "http://example.com", expected).Return(services.AnalysisResult{Title: "Example"}, nil)
+
+	// Test case: Options in the JSON body reach the service
+	r := gin.Default()
+	r.POST("/api/v1/analyze", handler.AnalyzePageJSON)
+	w := performJSONRequest(r, "/api/v1/analyze", `{"url": "http://example.com", "skip": ["links"], "timeout_ms": 5000, "cookies": {"session": "abc"}, "user_agent": "custom-agent/2.0"}`)
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"title":"Example"`)
+	mockAnalyzerService.AssertExpectations(t)
+}
+
+func TestAnalyzePageJSON_FieldErrors(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Create handler
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
+
+	// Define mock behavior
+	mockValidator.On("Validate", "invalid-url").Return(errors.New("invalid URL format"))
+
+	// Test case: Every invalid field is reported
+	r := gin.Default()
+	r.POST("/api/v1/analyze", handler.AnalyzePageJSON)
+	w := performJSONRequest(r, "/api/v1/analyze", `{"url": "invalid-url", "fetch_timeout_ms": -5, "headers": {"Host": "evil.com"}}`)
+
+	// Assertions
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.JSONEq(t, `{
+		"status": 400,
+		"error": "invalid request",
+		"fields": [
+			{"field": "url", "error": "invalid URL format"},
+			{"field": "fetch_timeout_ms", "error": "must be between 0 and 600000"},
+			{"field": "headers.Host", "error": "is set from url"}
+		]
+	}`, w.Body.String())
+	mockAnalyzerService.AssertNotCalled(t, "Analyze", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAnalyzePageJSON_UnknownAnalyzer(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Create handler
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
+
+	// Define mock behavior
+	mockValidator.On("Validate", "http://example.com").Return(nil)
+
+	// Test case: Misspelled analyzer names are reported per entry
+	r := gin.Default()
+	r.POST("/api/v1/analyze", handler.AnalyzePageJSON)
+	w := performJSONRequest(r, "/api/v1/analyze", `{"url": "http://example.com", "checks": ["title", "headngs"], "skip": ["link"]}`)
+
+	// Assertions
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `{"field":"checks[1]","error":"unknown analyzer: headngs"}`)
+	assert.Contains(t, w.Body.String(), `{"field":"skip[0]","error":"unknown analyzer: link"}`)
+	mockAnalyzerService.AssertNotCalled(t, "Analyze", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAnalyzePageJSON_UnknownField(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Create handler
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
+
+	// Test case: Misspelled option
+	r := gin.Default()
+	r.POST("/api/v1/analyze", handler.AnalyzePageJSON)
+	w := performJSONRequest(r, "/api/v1/analyze", `{"url": "http://example.com", "timeout": 5000}`)
+
+	// Assertions
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `{"field":"timeout","error":"unknown field"}`)
+	mockValidator.AssertNotCalled(t, "Validate", mock.Anything)
+}
+
+func TestAnalyzePageJSON_MalformedBody(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Create handler
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
+
+	// Test case: Body is not JSON
+	r := gin.Default()
+	r.POST("/api/v1/analyze", handler.AnalyzePageJSON)
+	w := performJSONRequest(r, "/api/v1/analyze", `url=http://example.com`)
+
+	// Assertions
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), ErrMalformedBody.Error())
+}
+
+func TestAnalyzePageJSON_BodyTooLarge(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Create handler
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
+
+	// Test case: Body exceeds the limit
+	r := gin.Default()
+	r.POST("/api/v1/analyze", handler.AnalyzePageJSON)
+	w := performJSONRequest(r, "/api/v1/analyze", `{"url": "http://example.com", "user_agent": "`+strings.Repeat("a", maxRequestBodySize)+`"}`)
+
+	// Assertions
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), ErrRequestTooLarge.Error())
+}
+
+func TestAnalyzePageJSON_AnalysisError(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Create handler
+	handler := NewAnalyzerHandler(mockAnalyzerService, mockValidator, testAnalyzers)
+
+	// Define mock behavior
+	mockValidator.On("Validate", "http://example.com").Return(nil)
+	mockAnalyzerService.On("Analyze", mock.Anything, "http://example.com", services.AnalyzeOptions{}).Return(services.AnalysisResult{}, services.ErrAnalysisTimeout)
+
+	// Test case: Service errors map to the same statuses as GET /analyze
+	r := gin.Default()
+	r.POST("/api/v1/analyze", handler.AnalyzePageJSON)
+	w := performJSONRequest(r, "/api/v1/analyze", `{"url": "http://example.com"}`)
+
+	// Assertions
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Contains(t, w.Body.String(), services.ErrAnalysisTimeout.Error())
+}
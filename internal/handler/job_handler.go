@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uikee/web-analyzer-service/config"
+	"github.com/uikee/web-analyzer-service/internal/jobs"
+	"github.com/uikee/web-analyzer-service/internal/validators"
+)
+
+// JobHandler provides HTTP handlers for asynchronous analysis jobs
+type JobHandler struct {
+	manager   *jobs.Manager
+	validator validators.URLValidator
+	analyzers []string
+}
+
+// NewJobHandler creates a new instance of JobHandler. Analyzers are the names the
+// manager's service knows, against which checks and skip are validated.
+func NewJobHandler(manager *jobs.Manager, validator validators.URLValidator, analyzers []string) *JobHandler {
+	return &JobHandler{manager: manager, validator: validator, analyzers: analyzers}
+}
+
+// CreateJob queues an analysis described by the same JSON body as POST
+// /api/v1/analyze, validated the same way, and responds with the new job right away
+func (h *JobHandler) CreateJob(c *gin.Context) {
+	req, ok := bindAnalyzeRequest(c, h.validator, h.analyzers)
+	if !ok {
+		return
+	}
+
+	job, err := h.manager.Submit(req.URL, req.options())
+	if err != nil {
+		handleError(c, jobErrorStatus(err), err, "Failed to queue analysis job")
+		return
+	}
+
+	config.Logger.Info().Str("job", job.ID).Str("url", job.URL).Msg("Analysis job queued")
+
+	c.Header("Location", "/jobs/"+job.ID)
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetJob responds with the status, progress and, once finished, the result of a job
+func (h *JobHandler) GetJob(c *gin.Context) {
+	job, err := h.manager.Get(c.Param("id"))
+	if err != nil {
+		handleError(c, jobErrorStatus(err), err, "Failed to look up analysis job")
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// CancelJob cancels a queued or running job
+func (h *JobHandler) CancelJob(c *gin.Context) {
+	job, err := h.manager.Cancel(c.Param("id"))
+	if err != nil {
+		handleError(c, jobErrorStatus(err), err, "Failed to cancel analysis job")
+		return
+	}
+
+	config.Logger.Info().Str("job", job.ID).Msg("Analysis job canceled")
+	c.JSON(http.StatusOK, job)
+}
+
+// jobErrorStatus maps job manager errors to HTTP status codes
+func jobErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, jobs.ErrJobNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, jobs.ErrJobFinished):
+		return http.StatusConflict
+	case errors.Is(err, jobs.ErrQueueFull), errors.Is(err, jobs.ErrManagerClosed):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/uikee/web-analyzer-service/config"
+	"github.com/uikee/web-analyzer-service/internal/jobs"
+	"github.com/uikee/web-analyzer-service/internal/services"
+)
+
+// newJobRouter serves a JobHandler backed by a single-worker manager the way RegisterRoutes does
+func newJobRouter(t *testing.T, service services.AnalyzerService, validator *MockURLValidator, cfg config.JobsConfig) *gin.Engine {
+	manager := jobs.NewManager(service, cfg)
+	t.Cleanup(manager.Close)
+
+	handler := NewJobHandler(manager, validator, testAnalyzers)
+	r := gin.Default()
+	r.POST("/jobs", handler.CreateJob)
+	r.GET("/jobs/:id", handler.GetJob)
+	r.DELETE("/jobs/:id", handler.CancelJob)
+	return r
+}
+
+func decodeJob(t *testing.T, body []byte) jobs.Job {
+	var job jobs.Job
+	require.NoError(t, json.Unmarshal(body, &job))
+	return job
+}
+
+func TestCreateJob_RunsToCompletion(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Define mock behavior
+	mockValidator.On("Validate", "http://example.com").Return(nil)
+	mockAnalyzerService.On("Analyze", mock.Anything, "http://example.com", mock.MatchedBy(func(opts services.AnalyzeOptions) bool {
+		return assert.ObjectsAreEqual([]string{"links"}, opts.Skip) && opts.OnProgress != nil
+	})).Return(services.AnalysisResult{Title: "Example"}, nil)
+
+	r := newJobRouter(t, mockAnalyzerService, mockValidator, config.JobsConfig{Workers: 1})
+
+	// Test case: Queue a job and poll it until it finishes
+	w := performJSONRequest(r, "/jobs", `{"url": "http://example.com", "skip": ["links"]}`)
+	require.Equal(t, http.StatusAccepted, w.Code)
+	created := decodeJob(t, w.Body.Bytes())
+	assert.Equal(t, "/jobs/"+created.ID, w.Header().Get("Location"))
+	assert.Equal(t, jobs.StatusQueued, created.Status)
+
+	var job jobs.Job
+	require.Eventually(t, func() bool {
+		w := performRequest(r, http.MethodGet, "/jobs/"+created.ID)
+		require.Equal(t, http.StatusOK, w.Code)
+		job = decodeJob(t, w.Body.Bytes())
+		return job.Finished()
+	}, 2*time.Second, 5*time.Millisecond)
+
+	// Assertions
+	assert.Equal(t, jobs.StatusSucceeded, job.Status)
+	require.NotNil(t, job.Result)
+	assert.Equal(t, "Example", job.Result.Title)
+	mockAnalyzerService.AssertExpectations(t)
+}
+
+func TestCreateJob_FieldErrors(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	r := newJobRouter(t, mockAnalyzerService, mockValidator, config.JobsConfig{})
+
+	// Test case: Invalid body is rejected like POST /api/v1/analyze
+	w := performJSONRequest(r, "/jobs", `{"timeout_ms": -1}`)
+
+	// Assertions
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `{"field":"url","error":"URL parameter is required"}`)
+	assert.Contains(t, w.Body.String(), `{"field":"timeout_ms","error":"must be between 0 and 600000"}`)
+}
+
+func TestCreateJob_UnknownAnalyzer(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Define mock behavior
+	mockValidator.On("Validate", "http://example.com").Return(nil)
+
+	r := newJobRouter(t, mockAnalyzerService, mockValidator, config.JobsConfig{})
+
+	// Test case: A misspelled analyzer is rejected before a job is queued
+	w := performJSONRequest(r, "/jobs", `{"url": "http://example.com", "checks": ["titel"]}`)
+
+	// Assertions
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `{"field":"checks[0]","error":"unknown analyzer: titel"}`)
+	mockAnalyzerService.AssertNotCalled(t, "Analyze", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateJob_QueueFull(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Define mock behavior: the first job blocks the only worker until it is canceled
+	started := make(chan struct{}, 1)
+	mockValidator.On("Validate", "http://example.com").Return(nil)
+	mockAnalyzerService.On("Analyze", mock.Anything, "http://example.com", mock.Anything).
+		Run(func(args mock.Arguments) {
+			started <- struct{}{}
+			<-args.Get(0).(context.Context).Done()
+		}).
+		Return(services.AnalysisResult{}, services.ErrAnalysisCanceled)
+
+	r := newJobRouter(t, mockAnalyzerService, mockValidator, config.JobsConfig{Workers: 1, QueueSize: 1})
+
+	// Test case: One running job, one queued job, then the queue is full
+	require.Equal(t, http.StatusAccepted, performJSONRequest(r, "/jobs", `{"url": "http://example.com"}`).Code)
+	<-started
+	require.Equal(t, http.StatusAccepted, performJSONRequest(r, "/jobs", `{"url": "http://example.com"}`).Code)
+	w := performJSONRequest(r, "/jobs", `{"url": "http://example.com"}`)
+
+	// Assertions
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), jobs.ErrQueueFull.Error())
+}
+
+func TestCancelJob(t *testing.T) {
+	// Create mock services
+	mockAnalyzerService := new(MockAnalyzerService)
+	mockValidator := new(MockURLValidator)
+
+	// Define mock behavior
+	started := make(chan struct{}, 1)
+	mockValidator.On("Validate", "http://example.com").Return(nil)
+	mockAnalyzerService.On("Analyze", mock.Anything, "http://example.com", mock.Anything).
+		Run(func(args mock.Arguments) {
+			started <- struct{}{}
+			<-args.Get(0).(context.Context).Done()
+		}).
+		Return(services.AnalysisResult{}, services.ErrAnalysisCanceled)
+
+	r := newJobRouter(t, mockAnalyzerService, mockValidator, config.JobsConfig{Workers: 1})
+
+	// Test case: Cancel a running job, then cancel it again
+	created := decodeJob(t, performJSONRequest(r, "/jobs", `{"url": "http://example.com"}`).Body.Bytes())
+	<-started
+	w := performRequest(r, http.MethodDelete, "/jobs/"+created.ID)
+	again := performRequest(r, http.MethodDelete, "/jobs/"+created.ID)
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, jobs.StatusCanceled, decodeJob(t, w.Body.Bytes()).Status)
+	assert.Equal(t, http.StatusConflict, again.Code)
+	assert.Contains(t, again.Body.String(), jobs.ErrJobFinished.Error())
+}
+
+func TestGetJob_NotFound(t *testing.T) {
+	r := newJobRouter(t, new(MockAnalyzerService), new(MockURLValidator), config.JobsConfig{})
+
+	// Test case: Unknown or expired job
+	get := performRequest(r, http.MethodGet, "/jobs/missing")
+	cancel := performRequest(r, http.MethodDelete, "/jobs/missing")
+
+	// Assertions
+	assert.Equal(t, http.StatusNotFound, get.Code)
+	assert.Contains(t, get.Body.String(), jobs.ErrJobNotFound.Error())
+	assert.Equal(t, http.StatusNotFound, cancel.Code)
+}
@@ -0,0 +1,302 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/uikee/web-analyzer-service/config"
+	"github.com/uikee/web-analyzer-service/internal/services"
+)
+
+// Defaults applied when the corresponding JobsConfig value is not positive
+const (
+	defaultWorkers   = 4
+	defaultQueueSize = 100
+	defaultRetention = time.Hour
+
+	// maxCleanupInterval bounds how long an expired job may outlive its retention
+	maxCleanupInterval = time.Minute
+)
+
+// Status is the state of a job
+type Status string
+
+// Job states. A job moves from queued to running and ends in one of the others.
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCanceled  Status = "canceled"
+)
+
+var (
+	// ErrJobNotFound indicates that no job has the given ID, or that it expired
+	ErrJobNotFound = errors.New("job not found")
+
+	// ErrQueueFull indicates that the job queue cannot take more jobs
+	ErrQueueFull = errors.New("job queue is full")
+
+	// ErrJobFinished indicates that a job could not be canceled because it already finished
+	ErrJobFinished = errors.New("job has already finished")
+
+	// ErrManagerClosed indicates that the manager no longer accepts jobs
+	ErrManagerClosed = errors.New("job manager is closed")
+)
+
+// Job is a snapshot of an analysis job
+type Job struct {
+	ID       string            `json:"id"`
+	Status   Status            `json:"status"`
+	URL      string            `json:"url"`
+	Progress services.Progress `json:"progress"`
+
+	// Result is set once the job succeeded, Error once it failed
+	Result *services.AnalysisResult `json:"result,omitempty"`
+	Error  string                   `json:"error,omitempty"`
+
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+
+	// ExpiresAt is when a finished job is removed
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Finished reports whether the job has reached a final state
+func (j Job) Finished() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed || j.Status == StatusCanceled
+}
+
+// job is a queued or running job together with what is needed to run it
+type job struct {
+	Job
+	opts   services.AnalyzeOptions
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Manager runs analyses in the background on a fixed pool of workers fed by an
+// in-process queue. Jobs live in memory only and are removed once their retention
+// elapses; they do not survive a restart.
+type Manager struct {
+	service   services.AnalyzerService
+	retention time.Duration
+	queue     chan *job
+
+	mu     sync.Mutex
+	jobs   map[string]*job
+	closed bool
+
+	ctx  context.Context
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+// NewManager creates a Manager and starts its workers. Close stops them.
+func NewManager(service services.AnalyzerService, cfg config.JobsConfig) *Manager {
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+
+	retention := cfg.Retention
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	m := &Manager{
+		service:   service,
+		retention: retention,
+		queue:     make(chan *job, queueSize),
+		jobs:      make(map[string]*job),
+		ctx:       ctx,
+		stop:      stop,
+	}
+
+	for i := 0; i < workers; i++ {
+		m.wg.Add(1)
+		go m.work()
+	}
+	m.wg.Add(1)
+	go m.cleanup(min(retention, maxCleanupInterval))
+
+	return m
+}
+
+// Submit queues an analysis of url and returns the new job without waiting for it
+func (m *Manager) Submit(url string, opts services.AnalyzeOptions) (Job, error) {
+	id, err := newID()
+	if err != nil {
+		return Job{}, err
+	}
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	j := &job{
+		Job:    Job{ID: id, Status: StatusQueued, URL: url, CreatedAt: time.Now()},
+		opts:   opts,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		cancel()
+		return Job{}, ErrManagerClosed
+	}
+
+	select {
+	case m.queue <- j:
+	default:
+		cancel()
+		return Job{}, ErrQueueFull
+	}
+	m.jobs[id] = j
+	return j.Job, nil
+}
+
+// Get returns the current state of a job
+func (m *Manager) Get(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	return j.Job, nil
+}
+
+// Cancel stops a queued or running job. The job is reported as canceled at once;
+// a running analysis stops shortly after.
+func (m *Manager) Cancel(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	if j.Finished() {
+		return j.Job, ErrJobFinished
+	}
+
+	j.cancel()
+	m.finish(j, StatusCanceled)
+	return j.Job, nil
+}
+
+// Close stops accepting jobs, cancels queued and running ones and waits for the
+// workers to exit
+func (m *Manager) Close() {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+
+	m.stop()
+	m.wg.Wait()
+}
+
+// work runs queued jobs until the manager is closed
+func (m *Manager) work() {
+	defer m.wg.Done()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case j := <-m.queue:
+			m.run(j)
+		}
+	}
+}
+
+// run performs the analysis of a single job and records its outcome
+func (m *Manager) run(j *job) {
+	m.mu.Lock()
+	if j.Status != StatusQueued {
+		// Canceled while it was waiting in the queue
+		m.mu.Unlock()
+		return
+	}
+	started := time.Now()
+	j.Status = StatusRunning
+	j.StartedAt = &started
+	m.mu.Unlock()
+
+	opts := j.opts
+	opts.OnProgress = func(p services.Progress) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		j.Progress = p
+	}
+
+	result, err := m.service.Analyze(j.ctx, j.URL, opts)
+	j.cancel()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case j.Finished():
+		// Canceled while running; the outcome is discarded
+	case m.ctx.Err() != nil:
+		m.finish(j, StatusCanceled)
+	case err != nil:
+		j.Error = err.Error()
+		m.finish(j, StatusFailed)
+	default:
+		j.Result = &result
+		m.finish(j, StatusSucceeded)
+	}
+}
+
+// finish moves a job into a final state. The caller must hold m.mu.
+func (m *Manager) finish(j *job, status Status) {
+	finished := time.Now()
+	expires := finished.Add(m.retention)
+	j.Status = status
+	j.FinishedAt = &finished
+	j.ExpiresAt = &expires
+}
+
+// cleanup removes expired jobs every interval until the manager is closed
+func (m *Manager) cleanup(interval time.Duration) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.mu.Lock()
+			for id, j := range m.jobs {
+				if j.ExpiresAt != nil && !now.Before(*j.ExpiresAt) {
+					delete(m.jobs, id)
+				}
+			}
+			m.mu.Unlock()
+		}
+	}
+}
+
+// newID returns a random job ID
+func newID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
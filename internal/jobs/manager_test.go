@@ -0,0 +1,215 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uikee/web-analyzer-service/config"
+	"github.com/uikee/web-analyzer-service/internal/services"
+)
+
+// fakeService runs analyze for every Analyze call
+type fakeService struct {
+	analyze func(ctx context.Context, url string, opts services.AnalyzeOptions) (services.AnalysisResult, error)
+}
+
+func (f *fakeService) Analyze(ctx context.Context, url string, opts services.AnalyzeOptions) (services.AnalysisResult, error) {
+	return f.analyze(ctx, url, opts)
+}
+
+func (f *fakeService) AnalyzeHTML(ctx context.Context, input services.HTMLInput, opts services.AnalyzeOptions) (services.AnalysisResult, error) {
+	return services.AnalysisResult{}, errors.New("not supported")
+}
+
+// blockingService blocks every analysis until it is canceled and signals when one starts
+func blockingService() (*fakeService, chan string) {
+	started := make(chan string, 10)
+	return &fakeService{analyze: func(ctx context.Context, url string, opts services.AnalyzeOptions) (services.AnalysisResult, error) {
+		started <- url
+		<-ctx.Done()
+		return services.AnalysisResult{}, services.ErrAnalysisCanceled
+	}}, started
+}
+
+func newTestManager(t *testing.T, service services.AnalyzerService, cfg config.JobsConfig) *Manager {
+	m := NewManager(service, cfg)
+	t.Cleanup(m.Close)
+	return m
+}
+
+// waitFor polls the job until it reaches a final state
+func waitFor(t *testing.T, m *Manager, id string) Job {
+	var job Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = m.Get(id)
+		require.NoError(t, err)
+		return job.Finished()
+	}, 2*time.Second, 5*time.Millisecond)
+	return job
+}
+
+func TestManager_RunsJob(t *testing.T) {
+	service := &fakeService{analyze: func(ctx context.Context, url string, opts services.AnalyzeOptions) (services.AnalysisResult, error) {
+		opts.OnProgress(services.Progress{Stage: services.StageAnalyzing, AnalyzersDone: 1, AnalyzersTotal: 1})
+		return services.AnalysisResult{Title: "Example"}, nil
+	}}
+	m := newTestManager(t, service, config.JobsConfig{Workers: 2})
+
+	submitted, err := m.Submit("http://example.com", services.AnalyzeOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, StatusQueued, submitted.Status)
+	assert.Len(t, submitted.ID, 32)
+
+	job := waitFor(t, m, submitted.ID)
+
+	assert.Equal(t, StatusSucceeded, job.Status)
+	require.NotNil(t, job.Result)
+	assert.Equal(t, "Example", job.Result.Title)
+	assert.Equal(t, services.Progress{Stage: services.StageAnalyzing, AnalyzersDone: 1, AnalyzersTotal: 1}, job.Progress)
+	assert.NotNil(t, job.StartedAt)
+	require.NotNil(t, job.FinishedAt)
+	assert.Equal(t, job.FinishedAt.Add(defaultRetention), *job.ExpiresAt)
+}
+
+func TestManager_RecordsFailure(t *testing.T) {
+	service := &fakeService{analyze: func(ctx context.Context, url string, opts services.AnalyzeOptions) (services.AnalysisResult, error) {
+		return services.AnalysisResult{}, services.ErrFetchFailed
+	}}
+	m := newTestManager(t, service, config.JobsConfig{})
+
+	submitted, err := m.Submit("http://example.com", services.AnalyzeOptions{})
+	require.NoError(t, err)
+	job := waitFor(t, m, submitted.ID)
+
+	assert.Equal(t, StatusFailed, job.Status)
+	assert.Equal(t, services.ErrFetchFailed.Error(), job.Error)
+	assert.Nil(t, job.Result)
+}
+
+func TestManager_CancelRunningJob(t *testing.T) {
+	service, started := blockingService()
+	m := newTestManager(t, service, config.JobsConfig{Workers: 1})
+
+	submitted, err := m.Submit("http://example.com", services.AnalyzeOptions{})
+	require.NoError(t, err)
+	<-started
+
+	job, err := m.Cancel(submitted.ID)
+
+	require.NoError(t, err)
+	assert.Equal(t, StatusCanceled, job.Status)
+
+	// The worker is freed for the next job
+	next, err := m.Submit("http://example.com/next", services.AnalyzeOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com/next", <-started)
+	_, _ = m.Cancel(next.ID)
+
+	job, err = m.Get(submitted.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCanceled, job.Status, "The canceled analysis should not overwrite the status")
+}
+
+func TestManager_CancelQueuedJob(t *testing.T) {
+	service, started := blockingService()
+	m := newTestManager(t, service, config.JobsConfig{Workers: 1})
+
+	running, err := m.Submit("http://example.com/running", services.AnalyzeOptions{})
+	require.NoError(t, err)
+	<-started
+	queued, err := m.Submit("http://example.com/queued", services.AnalyzeOptions{})
+	require.NoError(t, err)
+
+	job, err := m.Cancel(queued.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCanceled, job.Status)
+
+	// Freeing the worker must not start the canceled job
+	_, err = m.Cancel(running.ID)
+	require.NoError(t, err)
+	select {
+	case url := <-started:
+		t.Fatalf("canceled job %s was started", url)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestManager_CancelFinishedJob(t *testing.T) {
+	service := &fakeService{analyze: func(ctx context.Context, url string, opts services.AnalyzeOptions) (services.AnalysisResult, error) {
+		return services.AnalysisResult{}, nil
+	}}
+	m := newTestManager(t, service, config.JobsConfig{})
+
+	submitted, err := m.Submit("http://example.com", services.AnalyzeOptions{})
+	require.NoError(t, err)
+	waitFor(t, m, submitted.ID)
+
+	job, err := m.Cancel(submitted.ID)
+
+	assert.ErrorIs(t, err, ErrJobFinished)
+	assert.Equal(t, StatusSucceeded, job.Status)
+}
+
+func TestManager_QueueFull(t *testing.T) {
+	service, started := blockingService()
+	m := newTestManager(t, service, config.JobsConfig{Workers: 1, QueueSize: 1})
+
+	_, err := m.Submit("http://example.com/1", services.AnalyzeOptions{})
+	require.NoError(t, err)
+	<-started
+	_, err = m.Submit("http://example.com/2", services.AnalyzeOptions{})
+	require.NoError(t, err)
+
+	_, err = m.Submit("http://example.com/3", services.AnalyzeOptions{})
+
+	assert.ErrorIs(t, err, ErrQueueFull)
+}
+
+func TestManager_RemovesExpiredJobs(t *testing.T) {
+	service := &fakeService{analyze: func(ctx context.Context, url string, opts services.AnalyzeOptions) (services.AnalysisResult, error) {
+		return services.AnalysisResult{}, nil
+	}}
+	m := newTestManager(t, service, config.JobsConfig{Retention: 20 * time.Millisecond})
+
+	submitted, err := m.Submit("http://example.com", services.AnalyzeOptions{})
+	require.NoError(t, err)
+	waitFor(t, m, submitted.ID)
+
+	assert.Eventually(t, func() bool {
+		_, err := m.Get(submitted.ID)
+		return errors.Is(err, ErrJobNotFound)
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestManager_UnknownJob(t *testing.T) {
+	m := newTestManager(t, &fakeService{}, config.JobsConfig{})
+
+	_, err := m.Get("missing")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+
+	_, err = m.Cancel("missing")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+}
+
+func TestManager_Close(t *testing.T) {
+	service, started := blockingService()
+	m := NewManager(service, config.JobsConfig{Workers: 1})
+
+	submitted, err := m.Submit("http://example.com", services.AnalyzeOptions{})
+	require.NoError(t, err)
+	<-started
+
+	m.Close()
+
+	job, err := m.Get(submitted.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCanceled, job.Status)
+
+	_, err = m.Submit("http://example.com", services.AnalyzeOptions{})
+	assert.ErrorIs(t, err, ErrManagerClosed)
+}
@@ -3,24 +3,55 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/uikee/web-analyzer-service/config"
+	"github.com/uikee/web-analyzer-service/internal/fetcher"
 	"github.com/uikee/web-analyzer-service/internal/handler"
+	"github.com/uikee/web-analyzer-service/internal/jobs"
 	"github.com/uikee/web-analyzer-service/internal/services"
 	"github.com/uikee/web-analyzer-service/internal/validators"
 )
 
-// RegisterRoutes sets up API endpoints
-func RegisterRoutes(router *gin.Engine) {
+// RegisterRoutes sets up API endpoints. Extra analyzers are run in addition to the built-in ones.
+// The returned function stops the background job workers and must be called on shutdown.
+func RegisterRoutes(router *gin.Engine, cfg *config.Config, extra ...services.Analyzer) (func(), error) {
+	// Build the SSRF network policy shared by the validator and the fetcher
+	var policy *validators.NetworkPolicy
+	if cfg.SSRF.Enabled {
+		var err error
+		policy, err = validators.NewNetworkPolicy(cfg.SSRF)
+		if err != nil {
+			config.Logger.Error().Err(err).Msg("Failed to initialize network policy")
+			return nil, err
+		}
+	}
+
+	// Initialize the shared HTTP fetcher
+	httpFetcher, err := fetcher.NewHTTPFetcher(cfg.Fetch, policy)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Failed to initialize HTTP fetcher")
+		return nil, err
+	}
+
 	// Attempt to initialize the analyzer service
-	analyzerService := services.NewAnalyzerService()
+	registry, err := services.NewBuiltinRegistry(cfg, httpFetcher, extra...)
+	if err != nil {
+		config.Logger.Error().Err(err).Msg("Failed to initialize analyzer service")
+		return nil, err
+	}
+	analyzerService := services.NewAnalyzerServiceWithRegistry(cfg, httpFetcher, registry)
+
+	// Jobs share the analyzers and link-check limits but may run longer
+	jobsCfg := *cfg
+	jobsCfg.AnalysisTimeout = cfg.Jobs.AnalysisTimeout
+	jobManager := jobs.NewManager(services.NewAnalyzerServiceWithRegistry(&jobsCfg, httpFetcher, registry), cfg.Jobs)
 
 	// Initialize the URL validator
-	urlValidator := validators.NewURLValidator()
+	urlValidator := validators.NewURLValidator(policy)
 
 	// Log successful initialization of the service and validator
 	config.Logger.Info().Msg("Analyzer service and URL validator initialized successfully")
 
 	// Create the handler instance with both the service and validator
-	analyzerHandler := handler.NewAnalyzerHandler(analyzerService, urlValidator)
+	analyzerHandler := handler.NewAnalyzerHandler(analyzerService, urlValidator, registry.Names())
 
 	// Register the /analyze route and log the registration
 	router.GET("/analyze", func(c *gin.Context) {
@@ -29,6 +60,28 @@ func RegisterRoutes(router *gin.Engine) {
 		analyzerHandler.AnalyzePage(c)
 	})
 
+	// Register the JSON analysis endpoint, which accepts the full set of options
+	router.POST("/api/v1/analyze", func(c *gin.Context) {
+		config.Logger.Info().Msg("Received request for /api/v1/analyze endpoint")
+		analyzerHandler.AnalyzePageJSON(c)
+	})
+
+	// Register the endpoint analyzing documents supplied by the caller
+	router.POST("/api/v1/analyze/html", func(c *gin.Context) {
+		config.Logger.Info().Msg("Received request for /api/v1/analyze/html endpoint")
+		analyzerHandler.AnalyzeHTML(c)
+	})
+
+	// Register the asynchronous job endpoints
+	jobHandler := handler.NewJobHandler(jobManager, urlValidator, registry.Names())
+	router.POST("/jobs", func(c *gin.Context) {
+		config.Logger.Info().Msg("Received request for /jobs endpoint")
+		jobHandler.CreateJob(c)
+	})
+	router.GET("/jobs/:id", jobHandler.GetJob)
+	router.DELETE("/jobs/:id", jobHandler.CancelJob)
+
 	// Log successful route registration
 	config.Logger.Info().Msg("Routes registered successfully")
+	return jobManager.Close, nil
 }
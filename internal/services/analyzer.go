@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/uikee/web-analyzer-service/internal/fetcher"
+	"github.com/uikee/web-analyzer-service/internal/utils"
+)
+
+// PageMeta describes the fetched page an analyzer runs against
+type PageMeta struct {
+	// URL is the URL that was requested
+	URL string
+
+	// FinalURL is the URL the page was served from after following redirects.
+	// Relative references on the page resolve against it.
+	FinalURL string
+
+	// Hops lists every request made to reach the page; the last one served it
+	Hops []fetcher.Hop
+
+	StatusCode int
+	Proto      string
+	Header     http.Header
+
+	// BodySize is the size of the decoded body in bytes
+	BodySize int
+
+	// Truncated is set when the body exceeded the maximum size and was cut short
+	Truncated bool
+
+	// Compression is the content coding the body was sent with, if any
+	Compression string
+
+	// Encoding is the character encoding the body was decoded from
+	Encoding utils.EncodingInfo
+
+	// LinkCheck tunes link checking for this analysis
+	LinkCheck utils.LinkCheckOptions
+}
+
+// Analyzer is a single pluggable check run against a parsed page.
+// Run returns the analyzer's section of the result; see Section.
+type Analyzer interface {
+	Name() string
+	Version() string
+	Run(ctx context.Context, doc *utils.Document, meta PageMeta) (any, error)
+}
+
+// Section is implemented by analyzer outputs that fill in typed fields of an
+// AnalysisResult. Outputs that do not implement it are reported under
+// AnalysisResult.Extensions, keyed by the analyzer name.
+type Section interface {
+	Apply(result *AnalysisResult)
+}
+
+// AnalyzerReport records how a single analyzer run went
+type AnalyzerReport struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	DurationMs int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// analyzerFunc adapts a function to the Analyzer interface
+type analyzerFunc struct {
+	name    string
+	version string
+	run     func(ctx context.Context, doc *utils.Document, meta PageMeta) (any, error)
+}
+
+// NewAnalyzerFunc creates an Analyzer from a name, a version and a run function
+func NewAnalyzerFunc(name, version string, run func(ctx context.Context, doc *utils.Document, meta PageMeta) (any, error)) Analyzer {
+	return &analyzerFunc{name: name, version: version, run: run}
+}
+
+// Name returns the analyzer name
+func (a *analyzerFunc) Name() string { return a.name }
+
+// Version returns the analyzer version
+func (a *analyzerFunc) Version() string { return a.version }
+
+// Run calls the wrapped function
+func (a *analyzerFunc) Run(ctx context.Context, doc *utils.Document, meta PageMeta) (any, error) {
+	return a.run(ctx, doc, meta)
+}
@@ -1,60 +1,133 @@
 package services
 
 import (
+	"context"
 	"errors"
-	"io"
+	"fmt"
 	"net/http"
+	"slices"
+	"sync"
+	"time"
 
+	"github.com/uikee/web-analyzer-service/config"
+	"github.com/uikee/web-analyzer-service/internal/fetcher"
 	"github.com/uikee/web-analyzer-service/internal/utils"
+	"github.com/uikee/web-analyzer-service/internal/validators"
 )
 
 // AnalysisResult represents the result of a web analysis
 type AnalysisResult struct {
-	Title             string         `json:"title"`
-	HTMLVersion       string         `json:"html_version"`
-	Headings          map[string]int `json:"headings"`
-	InternalLinks     int            `json:"internal_links"`
-	ExternalLinks     int            `json:"external_links"`
-	InaccessibleLinks int            `json:"inaccessible_links"`
-	HasLoginForm      bool           `json:"has_login_form"`
+	Content             ContentInfo                `json:"content"`
+	Redirects           utils.RedirectChain        `json:"redirects"`
+	Response            utils.ResponseInfo         `json:"response"`
+	SecurityHeaders     utils.SecurityHeaders      `json:"security_headers"`
+	Title               string                     `json:"title"`
+	Metadata            utils.Metadata             `json:"metadata"`
+	HTMLVersion         string                     `json:"html_version"`
+	Doctype             utils.DoctypeInfo          `json:"doctype"`
+	Headings            map[string]int             `json:"headings"`
+	HeadingOutline      utils.HeadingOutline       `json:"heading_outline"`
+	Accessibility       utils.AccessibilityReport  `json:"accessibility"`
+	InternalLinks       int                        `json:"internal_links"`
+	ExternalLinks       int                        `json:"external_links"`
+	InaccessibleLinks   int                        `json:"inaccessible_links"`
+	Links               []utils.LinkResult         `json:"links"`
+	HasLoginForm        bool                       `json:"has_login_form"`
+	FormClassifications []utils.FormClassification `json:"form_classifications"`
+	Forms               []utils.FormInfo           `json:"forms"`
+	StructuredData      utils.StructuredData       `json:"structured_data"`
+	Truncated           bool                       `json:"truncated"`
+	Extensions          map[string]any             `json:"extensions,omitempty"`
+	Analyzers           []AnalyzerReport           `json:"analyzers"`
 }
 
-// AnalyzerService provides functionality to analyze web pages
-type AnalyzerService interface {
-	Analyze(url string) (AnalysisResult, error)
+// AnalyzeOptions selects which analyzers run for a single request and how the
+// page is fetched. Zero values keep the configured behavior.
+type AnalyzeOptions struct {
+	// Checks lists the analyzers to run; empty means all registered analyzers
+	Checks []string
+
+	// Skip lists analyzers to leave out, applied after Checks
+	Skip []string
+
+	// Timeout bounds the whole analysis. It can shorten the configured analysis
+	// timeout but never extend it.
+	Timeout time.Duration
+
+	// FetchTimeout bounds fetching the page itself
+	FetchTimeout time.Duration
+
+	// Header is sent with the page request, e.g. Cookie or User-Agent. It is not
+	// sent with link checks, so credentials never leave the analyzed site.
+	Header http.Header
+
+	// LinkCheck tunes how the page's links are checked
+	LinkCheck utils.LinkCheckOptions
+
+	// OnProgress, if set, is called whenever the analysis advances, e.g. after
+	// each analyzer and each checked link. Calls never overlap.
+	OnProgress func(Progress)
+}
+
+// HTMLInput is a document supplied by the caller instead of being fetched
+type HTMLInput struct {
+	Body []byte
+
+	// ContentType selects the parser and may name the charset, e.g.
+	// "application/xhtml+xml". Empty means the type is sniffed from Body.
+	ContentType string
+
+	// BaseURL is the URL the document is treated as served from. Relative links,
+	// form actions and metadata resolve against it; without it they stay relative.
+	BaseURL string
 }
 
-// UtilityFunctions encapsulates utility functions for testing or real use
-type UtilityFunctions struct {
-	CountHeadings       func(htmlContent string) map[string]int
-	ContainsLoginForm   func(htmlContent string) bool
-	CountLinksConcurrently func(baseURL, htmlContent string) (int, int, int, error)
-	ExtractTitle        func(htmlContent string) string
-	DetectHTMLVersion   func(htmlContent string) string
+// AnalyzerService provides functionality to analyze web pages
+type AnalyzerService interface {
+	Analyze(ctx context.Context, url string, opts AnalyzeOptions) (AnalysisResult, error)
+	AnalyzeHTML(ctx context.Context, input HTMLInput, opts AnalyzeOptions) (AnalysisResult, error)
 }
 
 // analyzerServiceImpl is the concrete implementation of AnalyzerService
 type analyzerServiceImpl struct {
-	utils UtilityFunctions
+	fetcher  fetcher.Fetcher
+	registry *Registry
+	timeout  time.Duration
 }
 
-// NewAnalyzerService creates a new instance of AnalyzerService with default utilities
-func NewAnalyzerService() AnalyzerService {
-	return &analyzerServiceImpl{
-		utils: UtilityFunctions{
-			CountHeadings:       utils.CountHeadings,
-			ContainsLoginForm:   utils.ContainsLoginForm,
-			CountLinksConcurrently: utils.CountLinksConcurrently,
-			ExtractTitle:        utils.ExtractTitle,
-			DetectHTMLVersion:   utils.DetectHTMLVersion,
-		},
+// NewAnalyzerService creates a new instance of AnalyzerService. Its registry holds
+// the built-in analyzers followed by any extra ones, e.g. in-house checks.
+// The fetcher is shared by the page fetch and the link checker.
+func NewAnalyzerService(cfg *config.Config, f fetcher.Fetcher, extra ...Analyzer) (AnalyzerService, error) {
+	registry, err := NewBuiltinRegistry(cfg, f, extra...)
+	if err != nil {
+		return nil, err
 	}
+	return NewAnalyzerServiceWithRegistry(cfg, f, registry), nil
 }
 
-// NewAnalyzerServiceWithUtils creates a new AnalyzerService with custom utilities (for testing)
-func NewAnalyzerServiceWithUtils(customUtils UtilityFunctions) AnalyzerService {
+// NewBuiltinRegistry creates a registry holding the built-in analyzers followed by
+// any extra ones. Services sharing the registry share its link checker and limits.
+func NewBuiltinRegistry(cfg *config.Config, f fetcher.Fetcher, extra ...Analyzer) (*Registry, error) {
+	registry := NewRegistry()
+	if err := RegisterBuiltinAnalyzers(registry, utils.NewLinkChecker(f, cfg.LinkCheck)); err != nil {
+		return nil, err
+	}
+
+	for _, a := range extra {
+		if err := registry.Register(a); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+// NewAnalyzerServiceWithRegistry creates a new AnalyzerService that runs exactly the analyzers in registry
+func NewAnalyzerServiceWithRegistry(cfg *config.Config, f fetcher.Fetcher, registry *Registry) AnalyzerService {
 	return &analyzerServiceImpl{
-		utils: customUtils,
+		fetcher:  f,
+		registry: registry,
+		timeout:  cfg.AnalysisTimeout,
 	}
 }
 
@@ -64,58 +137,313 @@ var (
 
 	// ErrReadBodyFailed indicates that reading the response body failed
 	ErrReadBodyFailed = errors.New("failed to read response body")
+
+	// ErrNon200StatusCode indicates that the URL returned a non-200 HTTP status code
+	ErrNon200StatusCode = errors.New("URL returned non-200 status")
+
+	// ErrParseFailed indicates that the page could not be parsed as HTML
+	ErrParseFailed = errors.New("failed to parse HTML content")
+
+	// ErrAnalysisTimeout indicates that the analysis exceeded its deadline
+	ErrAnalysisTimeout = errors.New("analysis timed out")
+
+	// ErrAnalysisCanceled indicates that the analysis was canceled, e.g. because the client disconnected
+	ErrAnalysisCanceled = errors.New("analysis canceled")
+
+	// ErrTargetNotAllowed indicates that the page or one of its redirects points to a host the network policy denies
+	ErrTargetNotAllowed = errors.New("target URL is not allowed by the network policy")
+
+	// ErrUnknownAnalyzer indicates that a requested analyzer is not registered
+	ErrUnknownAnalyzer = errors.New("unknown analyzer")
+
+	// ErrAnalyzerNeedsFetch indicates that an analyzer was requested for a supplied
+	// document although it describes the HTTP response
+	ErrAnalyzerNeedsFetch = errors.New("analyzer requires a fetched page")
 )
 
-// Analyze fetches the webpage and extracts analysis data
-func (s *analyzerServiceImpl) Analyze(targetURL string) (AnalysisResult, error) {
-	resp, err := http.Get(targetURL)
+// responseAnalyzers describe the HTTP response and its redirects, which documents
+// supplied to AnalyzeHTML do not have
+var responseAnalyzers = []string{AnalyzerResponse, AnalyzerSecurity, AnalyzerRedirects}
+
+// Analyze fetches the webpage and runs the selected analyzers on it. The analysis
+// stops when ctx is canceled or the analysis timeout elapses.
+// Documents other than HTML and XHTML are described without being analyzed.
+func (s *analyzerServiceImpl) Analyze(ctx context.Context, targetURL string, opts AnalyzeOptions) (AnalysisResult, error) {
+	// Resolve the selection before fetching so typos fail fast
+	analyzers, err := s.selectAnalyzers(opts)
 	if err != nil {
+		return AnalysisResult{}, err
+	}
+
+	ctx, cancel := s.withTimeout(ctx, opts)
+	defer cancel()
+
+	tracker := newProgressTracker(opts.OnProgress)
+	tracker.update(func(p *Progress) { p.Stage = StageFetching })
+
+	resp, err := s.fetcher.Fetch(ctx, fetcher.Request{
+		URL:         targetURL,
+		Header:      opts.Header,
+		Timeout:     opts.FetchTimeout,
+		AcceptTypes: htmlContentTypes,
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return AnalysisResult{}, contextError(ctx)
+		}
+		if errors.Is(err, fetcher.ErrReadBody) {
+			return AnalysisResult{}, ErrReadBodyFailed
+		}
+		if errors.Is(err, validators.ErrDisallowedHost) || errors.Is(err, validators.ErrDisallowedAddress) {
+			return AnalysisResult{}, ErrTargetNotAllowed
+		}
+		var redirectErr *fetcher.RedirectError
+		if errors.As(err, &redirectErr) && errors.Is(err, fetcher.ErrRedirectLoop) {
+			config.Logger.Warn().Str("url", targetURL).Int("hops", len(redirectErr.Hops)).Msg("Target redirects in a loop")
+			return s.redirectLoop(ctx, analyzers, targetURL, redirectErr.Hops, tracker)
+		}
+		var typeErr *fetcher.ContentTypeError
+		if errors.As(err, &typeErr) {
+			config.Logger.Info().Str("url", targetURL).Str("content_type", typeErr.ContentType).Msg("Target is not an HTML document")
+			return notHTMLError(typeErr), nil
+		}
 		return AnalysisResult{}, ErrFetchFailed
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	// The same response feeds both the status check and the analysis
+	if resp.StatusCode != http.StatusOK {
+		config.Logger.Warn().Str("url", targetURL).Int("status", resp.StatusCode).Msg("Target returned non-200 status")
+		return AnalysisResult{}, ErrNon200StatusCode
+	}
+
+	// Fetchers other than HTTPFetcher may not report where the page ended up
+	finalURL := resp.URL
+	if finalURL == "" {
+		finalURL = targetURL
+	}
+
+	meta := PageMeta{
+		URL:         targetURL,
+		FinalURL:    finalURL,
+		Hops:        resp.Hops,
+		StatusCode:  resp.StatusCode,
+		Proto:       resp.Proto,
+		Header:      resp.Header,
+		Truncated:   resp.Truncated,
+		Compression: resp.Compression,
+		LinkCheck:   opts.LinkCheck,
+	}
+
+	result, err := s.analyzeBody(ctx, analyzers, resp.Body, resp.Header.Get("Content-Type"), meta, tracker)
 	if err != nil {
-		return AnalysisResult{}, ErrReadBodyFailed
+		return AnalysisResult{}, err
 	}
+	result.Truncated = resp.Truncated
+	return result, nil
+}
 
-	htmlContent := string(body)
+// redirectLoop is the result for a URL that never stops redirecting. There is no
+// page to analyze, but the redirects analyzer, if selected, still describes the chain.
+func (s *analyzerServiceImpl) redirectLoop(ctx context.Context, analyzers []Analyzer, targetURL string, hops []fetcher.Hop, tracker *progressTracker) (AnalysisResult, error) {
+	analyzers = slices.DeleteFunc(analyzers, func(a Analyzer) bool {
+		return a.Name() != AnalyzerRedirects
+	})
+	tracker.update(func(p *Progress) {
+		p.Stage = StageAnalyzing
+		p.AnalyzersTotal = len(analyzers)
+	})
 
-	// Concurrent execution using channels
-	headingsChan := make(chan map[string]int)
-	loginFormChan := make(chan bool)
-	linkCountsChan := make(chan [3]int)
-	errorChan := make(chan error)
+	result, err := s.runAnalyzers(ctx, analyzers, nil, PageMeta{URL: targetURL, Hops: hops}, tracker)
+	if err != nil {
+		return AnalysisResult{}, err
+	}
+	result.Content = ContentInfo{Size: -1, Message: redirectLoopMessage}
+	return result, nil
+}
 
-	go func() { headingsChan <- s.utils.CountHeadings(htmlContent) }()
-	go func() { loginFormChan <- s.utils.ContainsLoginForm(htmlContent) }()
-	go func() {
-		internal, external, inaccessible, err := s.utils.CountLinksConcurrently(targetURL, htmlContent)
-		if err != nil {
-			errorChan <- err
-		} else {
-			linkCountsChan <- [3]int{internal, external, inaccessible}
+// AnalyzeHTML runs the selected analyzers on a document supplied by the caller
+// instead of fetching it. The analyzers describing the HTTP response and its
+// redirects are left out, and requesting them explicitly is an error.
+func (s *analyzerServiceImpl) AnalyzeHTML(ctx context.Context, input HTMLInput, opts AnalyzeOptions) (AnalysisResult, error) {
+	for _, name := range opts.Checks {
+		if slices.Contains(responseAnalyzers, name) {
+			return AnalysisResult{}, fmt.Errorf("%w: %s", ErrAnalyzerNeedsFetch, name)
 		}
-	}()
+	}
+
+	analyzers, err := s.selectAnalyzers(opts)
+	if err != nil {
+		return AnalysisResult{}, err
+	}
+	analyzers = slices.DeleteFunc(analyzers, func(a Analyzer) bool {
+		return slices.Contains(responseAnalyzers, a.Name())
+	})
+
+	ctx, cancel := s.withTimeout(ctx, opts)
+	defer cancel()
+
+	meta := PageMeta{
+		URL:       input.BaseURL,
+		FinalURL:  input.BaseURL,
+		LinkCheck: opts.LinkCheck,
+	}
+	return s.analyzeBody(ctx, analyzers, input.Body, input.ContentType, meta, newProgressTracker(opts.OnProgress))
+}
+
+// withTimeout bounds ctx by the analysis timeout. The returned cancel function
+// must always be called.
+func (s *analyzerServiceImpl) withTimeout(ctx context.Context, opts AnalyzeOptions) (context.Context, context.CancelFunc) {
+	if timeout := analysisTimeout(s.timeout, opts.Timeout); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return ctx, func() {}
+}
 
-	// Get results
-	headings := <-headingsChan
-	hasLoginForm := <-loginFormChan
+// analyzeBody parses a page body according to its content type and runs the
+// analyzers on it. Documents that are not HTML are described without being analyzed.
+func (s *analyzerServiceImpl) analyzeBody(ctx context.Context, analyzers []Analyzer, body []byte, contentType string, meta PageMeta, tracker *progressTracker) (AnalysisResult, error) {
+	// Pages served or supplied without a Content-Type are sniffed from their body
+	mediaType := sniffMediaType(contentType, body)
+	if !slices.Contains(htmlContentTypes, mediaType) {
+		config.Logger.Info().Str("url", meta.URL).Str("content_type", mediaType).Msg("Target is not an HTML document")
+		return notHTML(mediaType, int64(len(body))), nil
+	}
+
+	// Transcode to UTF-8 and parse once; every analyzer works on the same tree
+	doc, encoding, err := parseContent(body, contentType, mediaType)
+	if err != nil {
+		config.Logger.Error().Err(err).Str("url", meta.URL).Str("content_type", mediaType).Str("encoding", encoding.Encoding).Msg("Failed to parse page content")
+		return AnalysisResult{}, ErrParseFailed
+	}
+	meta.BodySize = len(body)
+	meta.Encoding = encoding
+
+	tracker.update(func(p *Progress) {
+		p.Stage = StageAnalyzing
+		p.AnalyzersTotal = len(analyzers)
+	})
+	if tracker != nil {
+		onLinkProgress := meta.LinkCheck.OnProgress
+		meta.LinkCheck.OnProgress = func(checked, total int) {
+			tracker.update(func(p *Progress) { p.LinksChecked, p.LinksTotal = checked, total })
+			if onLinkProgress != nil {
+				onLinkProgress(checked, total)
+			}
+		}
+	}
 
-	var linkCounts [3]int
-	select {
-	case linkCounts = <-linkCountsChan:
-	case err = <-errorChan:
+	result, err := s.runAnalyzers(ctx, analyzers, doc, meta, tracker)
+	if err != nil {
 		return AnalysisResult{}, err
 	}
+	result.Content = ContentInfo{IsHTML: true, ContentType: mediaType, Parser: ParserHTML, Encoding: &encoding, Size: int64(len(body))}
+	if doc.XML {
+		result.Content.Parser = ParserXML
+	}
+	return result, nil
+}
+
+// analysisTimeout returns the timeout of an analysis: the requested one when it is
+// shorter than the configured one, which is then only a ceiling
+func analysisTimeout(configured, requested time.Duration) time.Duration {
+	if requested > 0 && (configured <= 0 || requested < configured) {
+		return requested
+	}
+	return configured
+}
+
+// selectAnalyzers returns the registered analyzers chosen by opts, in registration order
+func (s *analyzerServiceImpl) selectAnalyzers(opts AnalyzeOptions) ([]Analyzer, error) {
+	for _, name := range append(append([]string(nil), opts.Checks...), opts.Skip...) {
+		if _, ok := s.registry.Get(name); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownAnalyzer, name)
+		}
+	}
+
+	checks := make(map[string]bool, len(opts.Checks))
+	for _, name := range opts.Checks {
+		checks[name] = true
+	}
+	skip := make(map[string]bool, len(opts.Skip))
+	for _, name := range opts.Skip {
+		skip[name] = true
+	}
+
+	var selected []Analyzer
+	for _, a := range s.registry.Analyzers() {
+		if (len(checks) == 0 || checks[a.Name()]) && !skip[a.Name()] {
+			selected = append(selected, a)
+		}
+	}
+	return selected, nil
+}
+
+// analyzerOutput is what a single analyzer run produced
+type analyzerOutput struct {
+	section any
+	report  AnalyzerReport
+}
+
+// runAnalyzers runs the analyzers concurrently and merges their sections in
+// registration order. A failing analyzer is reported without failing the analysis.
+func (s *analyzerServiceImpl) runAnalyzers(ctx context.Context, analyzers []Analyzer, doc *utils.Document, meta PageMeta, tracker *progressTracker) (AnalysisResult, error) {
+	outputs := make([]analyzerOutput, len(analyzers))
+
+	var wg sync.WaitGroup
+	for i, a := range analyzers {
+		wg.Add(1)
+		go func(i int, a Analyzer) {
+			defer wg.Done()
+
+			start := time.Now()
+			section, err := a.Run(ctx, doc, meta)
+
+			outputs[i] = analyzerOutput{
+				section: section,
+				report: AnalyzerReport{
+					Name:       a.Name(),
+					Version:    a.Version(),
+					DurationMs: time.Since(start).Milliseconds(),
+				},
+			}
+			if err != nil {
+				outputs[i].report.Error = err.Error()
+				config.Logger.Error().Err(err).Str("analyzer", a.Name()).Msg("Analyzer failed")
+			}
+			tracker.update(func(p *Progress) { p.AnalyzersDone++ })
+		}(i, a)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return AnalysisResult{}, contextError(ctx)
+	}
+
+	result := AnalysisResult{Analyzers: make([]AnalyzerReport, 0, len(outputs))}
+	for _, output := range outputs {
+		result.Analyzers = append(result.Analyzers, output.report)
 
-	return AnalysisResult{
-		Title:             s.utils.ExtractTitle(htmlContent),
-		HTMLVersion:       s.utils.DetectHTMLVersion(htmlContent),
-		Headings:          headings,
-		InternalLinks:     linkCounts[0],
-		ExternalLinks:     linkCounts[1],
-		InaccessibleLinks: linkCounts[2],
-		HasLoginForm:      hasLoginForm,
-	}, nil
+		switch section := output.section.(type) {
+		case nil:
+		case Section:
+			section.Apply(&result)
+		default:
+			if result.Extensions == nil {
+				result.Extensions = make(map[string]any)
+			}
+			result.Extensions[output.report.Name] = section
+		}
+	}
+
+	return result, nil
+}
+
+// contextError translates the reason a context ended into a service error
+func contextError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		config.Logger.Warn().Msg("Analysis timed out")
+		return ErrAnalysisTimeout
+	}
+	config.Logger.Warn().Msg("Analysis canceled")
+	return ErrAnalysisCanceled
 }
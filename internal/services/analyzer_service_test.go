@@ -1,43 +1,70 @@
 package services_test
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/uikee/web-analyzer-service/config"
+	"github.com/uikee/web-analyzer-service/internal/fetcher"
 	"github.com/uikee/web-analyzer-service/internal/services"
+	"github.com/uikee/web-analyzer-service/internal/utils"
+	"github.com/uikee/web-analyzer-service/internal/validators"
+	"golang.org/x/text/encoding/japanese"
 )
 
-// MockUtils is a mock implementation of utility functions
-type MockUtils struct {
+// MockAnalyzer is a mock implementation of the Analyzer interface
+type MockAnalyzer struct {
 	mock.Mock
+	name string
 }
 
-func (m *MockUtils) CountHeadings(htmlContent string) map[string]int {
-	args := m.Called(htmlContent)
-	return args.Get(0).(map[string]int)
+func (m *MockAnalyzer) Name() string { return m.name }
+
+func (m *MockAnalyzer) Version() string { return "test" }
+
+func (m *MockAnalyzer) Run(ctx context.Context, doc *utils.Document, meta services.PageMeta) (any, error) {
+	args := m.Called(ctx, doc, meta)
+	return args.Get(0), args.Error(1)
 }
 
-func (m *MockUtils) ContainsLoginForm(htmlContent string) bool {
-	args := m.Called(htmlContent)
-	return args.Bool(0)
+func newTestFetcher(t *testing.T) fetcher.Fetcher {
+	f, err := fetcher.NewHTTPFetcher(config.FetchConfig{Timeout: 5 * time.Second}, nil)
+	if err != nil {
+		t.Fatalf("failed to create fetcher: %v", err)
+	}
+	return f
 }
 
-func (m *MockUtils) CountLinksConcurrently(baseURL, htmlContent string) (int, int, int, error) {
-	args := m.Called(baseURL, htmlContent)
-	return args.Int(0), args.Int(1), args.Int(2), args.Error(3)
+// newTestService builds a service that runs only the given analyzers
+func newTestService(t *testing.T, cfg *config.Config, analyzers ...services.Analyzer) services.AnalyzerService {
+	registry := services.NewRegistry()
+	for _, a := range analyzers {
+		require.NoError(t, registry.Register(a))
+	}
+	return services.NewAnalyzerServiceWithRegistry(cfg, newTestFetcher(t), registry)
 }
 
-func (m *MockUtils) ExtractTitle(htmlContent string) string {
-	args := m.Called(htmlContent)
-	return args.String(0)
+// newDefaultService builds a service with the built-in analyzers
+func newDefaultService(t *testing.T) services.AnalyzerService {
+	service, err := services.NewAnalyzerService(&config.Config{}, newTestFetcher(t))
+	require.NoError(t, err)
+	return service
 }
 
-func (m *MockUtils) DetectHTMLVersion(htmlContent string) string {
-	args := m.Called(htmlContent)
-	return args.String(0)
+// constant returns an analyzer that always produces the given section
+func constant(name string, section any) services.Analyzer {
+	return services.NewAnalyzerFunc(name, "test", func(ctx context.Context, doc *utils.Document, meta services.PageMeta) (any, error) {
+		return section, nil
+	})
 }
 
 func TestAnalyze_Success(t *testing.T) {
@@ -50,30 +77,19 @@ func TestAnalyze_Success(t *testing.T) {
 	}))
 	defer server.Close()
 
-	// Mock utility functions
-	mockUtils := services.UtilityFunctions{
-		CountHeadings: func(htmlContent string) map[string]int {
-			return map[string]int{"h1": 1}
-		},
-		ContainsLoginForm: func(htmlContent string) bool {
-			return false
-		},
-		CountLinksConcurrently: func(baseURL, htmlContent string) (int, int, int, error) {
-			return 1, 0, 0, nil
-		},
-		ExtractTitle: func(htmlContent string) string {
-			return "Test Page"
-		},
-		DetectHTMLVersion: func(htmlContent string) string {
-			return "HTML5"
-		},
-	}
-
-	// Create the service with mock utilities
-	service := services.NewAnalyzerServiceWithUtils(mockUtils)
+	// Mock analyzers
+	service := newTestService(t, &config.Config{},
+		constant(services.AnalyzerTitle, services.TitleSection{Title: "Test Page"}),
+		constant(services.AnalyzerHTMLVersion, services.HTMLVersionSection{HTMLVersion: "HTML5"}),
+		constant(services.AnalyzerHeadings, services.HeadingsSection{Headings: map[string]int{"h1": 1}}),
+		constant(services.AnalyzerLinks, services.LinksSection{Links: []utils.LinkResult{
+			{Href: "/", URL: server.URL + "/", Type: utils.LinkTypeInternal, Accessible: true, StatusCode: 200},
+		}}),
+		constant(services.AnalyzerLoginForm, services.LoginFormSection{HasLoginForm: false}),
+	)
 
 	// Call the Analyze method
-	result, err := service.Analyze(server.URL)
+	result, err := service.Analyze(context.Background(), server.URL, services.AnalyzeOptions{})
 
 	// Assertions
 	assert.NoError(t, err)
@@ -83,14 +99,39 @@ func TestAnalyze_Success(t *testing.T) {
 	assert.Equal(t, 1, result.InternalLinks)
 	assert.Equal(t, 0, result.ExternalLinks)
 	assert.Equal(t, 0, result.InaccessibleLinks)
+	assert.Len(t, result.Links, 1)
 	assert.False(t, result.HasLoginForm)
+	assert.Len(t, result.Analyzers, 5)
+}
+
+func TestAnalyze_DefaultAnalyzers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<!DOCTYPE html><html><head><title>Built-in</title></head><body><h2>Section</h2></body></html>`))
+	}))
+	defer server.Close()
+
+	// Call the Analyze method
+	result, err := newDefaultService(t).Analyze(context.Background(), server.URL, services.AnalyzeOptions{})
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, "Built-in", result.Title)
+	assert.Equal(t, "HTML5", result.HTMLVersion)
+	assert.Equal(t, map[string]int{"h2": 1}, result.Headings)
+
+	var names []string
+	for _, report := range result.Analyzers {
+		names = append(names, report.Name)
+		assert.Empty(t, report.Error)
+	}
+	assert.Equal(t, []string{"title", "html_version", "headings", "links", "login_form", "forms", "metadata", "structured_data", "accessibility", "response", "security_headers", "redirects"}, names)
 }
 
 func TestAnalyze_FetchFailed(t *testing.T) {
-	service := services.NewAnalyzerService()
+	service := newDefaultService(t)
 
 	// Call the Analyze method with an invalid URL
-	_, err := service.Analyze("http://invalid-url")
+	_, err := service.Analyze(context.Background(), "http://invalid-url", services.AnalyzeOptions{})
 
 	// Assertions
 	assert.Error(t, err)
@@ -104,41 +145,619 @@ func TestAnalyze_ReadBodyFailed(t *testing.T) {
 	}))
 	defer server.Close()
 
-	service := services.NewAnalyzerService()
+	service := newDefaultService(t)
 
 	// Call the Analyze method
-	_, err := service.Analyze(server.URL)
+	_, err := service.Analyze(context.Background(), server.URL, services.AnalyzeOptions{})
 
 	// Assertions
 	assert.Error(t, err)
 	assert.Equal(t, services.ErrReadBodyFailed, err)
 }
 
-// func TestAnalyze_UtilityError(t *testing.T) {
-// 	// Mock HTTP server
-// 	mockHTMLContent := "<html><head><title>Test Page</title></head><body><h1>Heading 1</h1><a href=\"http://example.com\">Link</a></body></html>"
-// 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-// 		w.WriteHeader(http.StatusOK)
-// 		_, _ = w.Write([]byte(mockHTMLContent))
-// 	}))
-// 	defer server.Close()
+func TestAnalyze_Non200StatusCode(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	service := newDefaultService(t)
+
+	// Call the Analyze method
+	_, err := service.Analyze(context.Background(), server.URL, services.AnalyzeOptions{})
+
+	// Assertions
+	assert.Equal(t, services.ErrNon200StatusCode, err)
+	assert.Equal(t, 1, requests, "Target should be fetched exactly once")
+}
+
+func TestAnalyze_Canceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	service := newDefaultService(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Call the Analyze method with an already canceled context
+	_, err := service.Analyze(ctx, server.URL, services.AnalyzeOptions{})
+
+	// Assertions
+	assert.Equal(t, services.ErrAnalysisCanceled, err)
+}
+
+func TestAnalyze_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><body><a href="/slow">Slow</a></body></html>`))
+	}))
+	defer server.Close()
+
+	// The analyzer blocks until the analysis deadline fires
+	blocking := services.NewAnalyzerFunc("blocking", "test", func(ctx context.Context, doc *utils.Document, meta services.PageMeta) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	cfg := &config.Config{AnalysisTimeout: 50 * time.Millisecond}
+	service := newTestService(t, cfg, blocking)
+
+	// Call the Analyze method
+	_, err := service.Analyze(context.Background(), server.URL, services.AnalyzeOptions{})
+
+	// Assertions
+	assert.Equal(t, services.ErrAnalysisTimeout, err)
+}
+
+func TestAnalyze_RequestedTimeoutShortensConfigured(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><body>Slow analysis</body></html>`))
+	}))
+	defer server.Close()
+
+	blocking := services.NewAnalyzerFunc("blocking", "test", func(ctx context.Context, doc *utils.Document, meta services.PageMeta) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	cfg := &config.Config{AnalysisTimeout: time.Minute}
+	service := newTestService(t, cfg, blocking)
+
+	// Call the Analyze method with a shorter timeout than configured
+	start := time.Now()
+	_, err := service.Analyze(context.Background(), server.URL, services.AnalyzeOptions{Timeout: 50 * time.Millisecond})
+
+	// Assertions
+	assert.Equal(t, services.ErrAnalysisTimeout, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestAnalyze_SendsRequestHeaders(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		_, _ = w.Write([]byte(`<html><body>Headers</body></html>`))
+	}))
+	defer server.Close()
+
+	service := newTestService(t, &config.Config{}, constant("noop", nil))
+
+	// Call the Analyze method with custom headers, a cookie and a user agent
+	header := http.Header{}
+	header.Set("Accept-Language", "de")
+	header.Set("Cookie", "session=abc")
+	header.Set("User-Agent", "custom-agent/2.0")
+	_, err := service.Analyze(context.Background(), server.URL, services.AnalyzeOptions{Header: header})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, "de", received.Get("Accept-Language"))
+	assert.Equal(t, "session=abc", received.Get("Cookie"))
+	assert.Equal(t, "custom-agent/2.0", received.Get("User-Agent"))
+}
+
+func TestAnalyze_SharesParsedDocument(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><head><title>Shared</title></head></html>`))
+	}))
+	defer server.Close()
+
+	// Every analyzer records the document it was handed
+	var mu sync.Mutex
+	seen := map[*utils.Document]bool{}
+	recorder := func(name string) services.Analyzer {
+		return services.NewAnalyzerFunc(name, "test", func(ctx context.Context, doc *utils.Document, meta services.PageMeta) (any, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			seen[doc] = true
+			return nil, nil
+		})
+	}
+
+	service := newTestService(t, &config.Config{}, recorder("a"), recorder("b"), recorder("c"))
+
+	// Call the Analyze method
+	_, err := service.Analyze(context.Background(), server.URL, services.AnalyzeOptions{})
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Len(t, seen, 1, "All analyzers should share one parsed document")
+}
+
+func TestAnalyze_CustomAnalyzer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Team", "search")
+		_, _ = w.Write([]byte(`<html><body>In-house</body></html>`))
+	}))
+	defer server.Close()
+
+	type teamReport struct {
+		Team string `json:"team"`
+	}
+
+	// An in-house analyzer with its own result type
+	inHouse := &MockAnalyzer{name: "team"}
+	inHouse.On("Run", mock.Anything, mock.Anything, mock.MatchedBy(func(meta services.PageMeta) bool {
+		return meta.URL == server.URL && meta.StatusCode == http.StatusOK
+	})).Return(teamReport{Team: "search"}, nil)
+
+	service, err := services.NewAnalyzerService(&config.Config{}, newTestFetcher(t), inHouse)
+	require.NoError(t, err)
+
+	// Call the Analyze method
+	result, err := service.Analyze(context.Background(), server.URL, services.AnalyzeOptions{})
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, teamReport{Team: "search"}, result.Extensions["team"])
+	inHouse.AssertExpectations(t)
+}
+
+func TestAnalyze_FailingAnalyzerIsReported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><head><title>Still here</title></head></html>`))
+	}))
+	defer server.Close()
+
+	failing := services.NewAnalyzerFunc("failing", "test", func(ctx context.Context, doc *utils.Document, meta services.PageMeta) (any, error) {
+		return nil, errors.New("boom")
+	})
+	service := newTestService(t, &config.Config{}, constant(services.AnalyzerTitle, services.TitleSection{Title: "Still here"}), failing)
+
+	// Call the Analyze method
+	result, err := service.Analyze(context.Background(), server.URL, services.AnalyzeOptions{})
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, "Still here", result.Title)
+	assert.Equal(t, "boom", result.Analyzers[1].Error)
+}
+
+func TestNewAnalyzerService_DuplicateAnalyzer(t *testing.T) {
+	_, err := services.NewAnalyzerService(&config.Config{}, newTestFetcher(t), constant(services.AnalyzerTitle, nil))
+
+	assert.ErrorIs(t, err, services.ErrDuplicateAnalyzer)
+}
+
+func TestAnalyze_SelectsAnalyzers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html></html>`))
+	}))
+	defer server.Close()
+
+	service := newTestService(t, &config.Config{}, constant("title", nil), constant("headings", nil), constant("links", nil))
+
+	testCases := []struct {
+		opts     services.AnalyzeOptions
+		expected []string
+	}{
+		{services.AnalyzeOptions{}, []string{"title", "headings", "links"}},
+		{services.AnalyzeOptions{Checks: []string{"links", "title"}}, []string{"title", "links"}},
+		{services.AnalyzeOptions{Skip: []string{"links"}}, []string{"title", "headings"}},
+		{services.AnalyzeOptions{Checks: []string{"title", "links"}, Skip: []string{"links"}}, []string{"title"}},
+	}
+
+	for _, testCase := range testCases {
+		result, err := service.Analyze(context.Background(), server.URL, testCase.opts)
+		assert.NoError(t, err)
+
+		var names []string
+		for _, report := range result.Analyzers {
+			names = append(names, report.Name)
+		}
+		assert.Equal(t, testCase.expected, names, "Selection %+v", testCase.opts)
+	}
+}
+
+func TestAnalyze_UnknownAnalyzerFailsBeforeFetch(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	service := newTestService(t, &config.Config{}, constant("title", nil))
+
+	// Call the Analyze method with a misspelled analyzer
+	_, err := service.Analyze(context.Background(), server.URL, services.AnalyzeOptions{Skip: []string{"titel"}})
+
+	// Assertions
+	assert.ErrorIs(t, err, services.ErrUnknownAnalyzer)
+	assert.Equal(t, 0, requests, "Target should not be fetched")
+}
+
+func TestAnalyze_TargetNotAllowed(t *testing.T) {
+	// Mock HTTP server that redirects to an internal address
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	policy, err := validators.NewNetworkPolicy(config.SSRFConfig{AllowCIDRs: []string{"127.0.0.1"}})
+	require.NoError(t, err)
+	f, err := fetcher.NewHTTPFetcher(config.FetchConfig{Timeout: 5 * time.Second}, policy)
+	require.NoError(t, err)
+
+	service := services.NewAnalyzerServiceWithRegistry(&config.Config{}, f, services.NewRegistry())
+
+	// Call the Analyze method
+	_, err = service.Analyze(context.Background(), server.URL, services.AnalyzeOptions{})
+
+	// Assertions
+	assert.Equal(t, services.ErrTargetNotAllowed, err)
+}
+
+func TestAnalyze_ResponseDetailsReachAnalyzers(t *testing.T) {
+	body := `<html><body>Response details</body></html>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	service, err := services.NewAnalyzerService(&config.Config{}, newTestFetcher(t))
+	require.NoError(t, err)
+
+	// Call the Analyze method with only the response analyzers
+	result, err := service.Analyze(context.Background(), server.URL, services.AnalyzeOptions{
+		Checks: []string{services.AnalyzerResponse, services.AnalyzerSecurity},
+	})
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, result.Response.StatusCode)
+	assert.Equal(t, "HTTP/1.1", result.Response.Protocol)
+	assert.Equal(t, "text/html", result.Response.ContentType)
+	assert.Equal(t, len(body), result.Response.Size)
+	assert.Len(t, result.SecurityHeaders.Headers, 8)
+}
+
+func TestAnalyze_FollowsRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/start":
+			http.Redirect(w, r, "/docs/", http.StatusMovedPermanently)
+		case "/docs/":
+			_, _ = w.Write([]byte(`<html><body><a href="guide">Guide</a></body></html>`))
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	service := newDefaultService(t)
+
+	// Call the Analyze method with a URL that redirects
+	result, err := service.Analyze(context.Background(), server.URL+"/start", services.AnalyzeOptions{
+		Checks: []string{services.AnalyzerLinks, services.AnalyzerRedirects},
+	})
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result.Redirects.Redirects)
+	assert.Equal(t, server.URL+"/docs/", result.Redirects.FinalURL)
+	require.Len(t, result.Links, 1)
+	assert.Equal(t, server.URL+"/docs/guide", result.Links[0].URL, "Links resolve against the final URL")
+	assert.True(t, result.Links[0].Accessible)
+}
+
+func TestAnalyze_RedirectLoop(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Path, http.StatusFound)
+	}))
+	defer server.Close()
+
+	service := newDefaultService(t)
+
+	// Call the Analyze method with a URL that redirects to itself
+	result, err := service.Analyze(context.Background(), server.URL+"/loop", services.AnalyzeOptions{})
+
+	// Assertions: the chain is reported instead of failing the analysis
+	require.NoError(t, err)
+	assert.False(t, result.Content.IsHTML)
+	assert.Equal(t, "URL redirects in a loop", result.Content.Message)
+	assert.Empty(t, result.Title)
+	require.Len(t, result.Analyzers, 1)
+	assert.Equal(t, services.AnalyzerRedirects, result.Analyzers[0].Name)
+
+	assert.NotEmpty(t, result.Redirects.Hops)
+	assert.Equal(t, server.URL+"/loop", result.Redirects.Hops[0].URL)
+	assert.Empty(t, result.Redirects.FinalURL)
+	require.NotEmpty(t, result.Redirects.Findings)
+	assert.Equal(t, utils.RuleRedirectLoop, result.Redirects.Findings[len(result.Redirects.Findings)-1].Rule)
+}
+
+func TestAnalyze_DecodesLegacyEncodings(t *testing.T) {
+	body, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte(`<html><head><title>日本語のページ</title></head><body><h1>見出し</h1></body></html>`))
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=Shift_JIS")
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	service := newDefaultService(t)
+
+	// Call the Analyze method with a Shift_JIS page; the encoding is reported without the response analyzer
+	result, err := service.Analyze(context.Background(), server.URL, services.AnalyzeOptions{
+		Checks: []string{services.AnalyzerTitle, services.AnalyzerHeadings},
+	})
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, "日本語のページ", result.Title)
+	assert.Equal(t, "見出し", result.HeadingOutline.Headings[0].Text)
+	assert.Equal(t, utils.EncodingInfo{Encoding: "shift_jis", Source: utils.EncodingSourceHeader}, *result.Content.Encoding)
+}
+
+func TestAnalyze_UnsupportedContentType(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/pdf")
+		_, _ = w.Write([]byte("%PDF-1.7"))
+	}))
+	defer server.Close()
+
+	service := newDefaultService(t)
+
+	// Call the Analyze method with a URL serving a PDF
+	result, err := service.Analyze(context.Background(), server.URL, services.AnalyzeOptions{})
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, services.ContentInfo{ContentType: "application/pdf", Size: 8, Message: "not an HTML document"}, result.Content)
+	assert.Empty(t, result.Analyzers, "No analyzer runs on a document that is not HTML")
+	assert.Equal(t, 1, requests)
+}
+
+func TestAnalyze_SniffsMissingContentType(t *testing.T) {
+	body := `{"title": "not a page", "headings": ["<h1>"]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header()["Content-Type"] = nil // Stop the server from sniffing the type itself
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
 
-// 	// Mock utility functions
-// 	mockUtils := &MockUtils{}
-// 	mockUtils.On("CountHeadings", mockHTMLContent).Return(map[string]int{"h1": 1})
-// 	mockUtils.On("ContainsLoginForm", mockHTMLContent).Return(false)
-// 	mockUtils.On("CountLinksConcurrently", server.URL, mockHTMLContent).Return(0, 0, 0, errors.New("utility error"))
+	service := newDefaultService(t)
 
-// 	// Replace utils with mockUtils
-// 	services.SetUtils(mockUtils)
+	// Call the Analyze method with a JSON document served without a Content-Type
+	result, err := service.Analyze(context.Background(), server.URL, services.AnalyzeOptions{})
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.False(t, result.Content.IsHTML)
+	assert.Equal(t, "text/plain", result.Content.ContentType)
+	assert.Equal(t, int64(len(body)), result.Content.Size)
+	assert.Empty(t, result.Headings)
+}
+
+func TestAnalyze_XHTML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xhtml+xml")
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="ISO-8859-1"?>
+<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.1//EN" "http://www.w3.org/TR/xhtml11/DTD/xhtml11.dtd">
+<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="fr">
+<head><title>Caf` + "\xe9" + `&nbsp;XHTML</title></head>
+<body>
+	<h1>Titre</h1>
+	<svg xmlns="http://www.w3.org/2000/svg"><title>Icon</title><a href="/not-a-link">x</a></svg>
+	<a href="/inside">Inside</a>
+</body>
+</html>`))
+	}))
+	defer server.Close()
 
-// 	// Create the service
-// 	service := services.NewAnalyzerService()
+	service := newDefaultService(t)
 
-// 	// Call the Analyze method
-// 	_, err := service.Analyze(server.URL)
+	// Call the Analyze method with an XHTML document served as XML
+	result, err := service.Analyze(context.Background(), server.URL, services.AnalyzeOptions{
+		Skip: []string{services.AnalyzerLinks},
+	})
 
-// 	// Assertions
-// 	assert.Error(t, err)
-// 	assert.Equal(t, "utility error", err.Error())
-// }
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, services.ContentInfo{
+		IsHTML:      true,
+		ContentType: "application/xhtml+xml",
+		Parser:      services.ParserXML,
+		Encoding:    &utils.EncodingInfo{Encoding: "windows-1252", Source: utils.EncodingSourceXMLDeclaration},
+		Size:        result.Content.Size,
+	}, result.Content)
+	assert.Equal(t, "Caf\u00e9\u00a0XHTML", result.Title)
+	assert.Equal(t, "XHTML 1.1", result.HTMLVersion)
+	assert.Equal(t, utils.RenderingModeNoQuirks, result.Doctype.RenderingMode)
+	assert.Equal(t, map[string]int{"h1": 1}, result.Headings)
+}
+
+func TestAnalyze_MalformedXHTML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xhtml+xml")
+		_, _ = w.Write([]byte(`<html xmlns="http://www.w3.org/1999/xhtml"><body><p>Unclosed</body></html>`))
+	}))
+	defer server.Close()
+
+	service := newDefaultService(t)
+
+	// Call the Analyze method with XHTML that is not well-formed
+	_, err := service.Analyze(context.Background(), server.URL, services.AnalyzeOptions{})
+
+	// Assertions
+	assert.Equal(t, services.ErrParseFailed, err)
+}
+
+func TestAnalyze_TruncatedPage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><head><title>Big page</title></head><body>" + strings.Repeat("<p>filler</p>", 1000) + "</body></html>"))
+	}))
+	defer server.Close()
+
+	f, err := fetcher.NewHTTPFetcher(config.FetchConfig{MaxBodySize: 512}, nil)
+	require.NoError(t, err)
+	service, err := services.NewAnalyzerService(&config.Config{}, f)
+	require.NoError(t, err)
+
+	// Call the Analyze method with a page larger than the maximum body size
+	result, err := service.Analyze(context.Background(), server.URL, services.AnalyzeOptions{
+		Checks: []string{services.AnalyzerTitle, services.AnalyzerResponse},
+	})
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.True(t, result.Truncated)
+	assert.Equal(t, "Big page", result.Title, "The beginning of the page is still analyzed")
+	assert.Equal(t, 512, result.Response.Size)
+}
+
+func TestAnalyzeHTML(t *testing.T) {
+	service := newDefaultService(t)
+	page := `<!DOCTYPE html>
+<html lang="en"><head><title>Staging build</title><link rel="canonical" href="/pricing"></head>
+<body><h1>Pricing</h1><form method="post" action="subscribe"><input type="email" name="email"></form></body></html>`
+
+	// Call the AnalyzeHTML method without the link checks
+	result, err := service.AnalyzeHTML(context.Background(), services.HTMLInput{
+		Body:    []byte(page),
+		BaseURL: "https://staging.example.com/plans/",
+	}, services.AnalyzeOptions{Skip: []string{services.AnalyzerLinks}})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.True(t, result.Content.IsHTML)
+	assert.Equal(t, "text/html", result.Content.ContentType)
+	assert.Equal(t, int64(len(page)), result.Content.Size)
+	assert.Equal(t, "Staging build", result.Title)
+	assert.Equal(t, "https://staging.example.com/pricing", result.Metadata.Canonical)
+	require.Len(t, result.Forms, 1)
+	assert.Equal(t, "https://staging.example.com/plans/subscribe", result.Forms[0].ResolvedAction)
+
+	// The response analyzers have nothing to describe
+	for _, report := range result.Analyzers {
+		assert.NotContains(t, []string{services.AnalyzerResponse, services.AnalyzerSecurity, services.AnalyzerRedirects}, report.Name)
+	}
+	assert.Empty(t, result.Redirects.Hops)
+	assert.Empty(t, result.SecurityHeaders.Headers)
+}
+
+func TestAnalyzeHTML_ReportsEncoding(t *testing.T) {
+	service := newDefaultService(t)
+
+	// Call the AnalyzeHTML method with a windows-1252 upload
+	result, err := service.AnalyzeHTML(context.Background(), services.HTMLInput{
+		Body:        []byte("<html><head><title>Caf\xe9</title></head></html>"),
+		ContentType: "text/html; charset=windows-1252",
+	}, services.AnalyzeOptions{Checks: []string{services.AnalyzerTitle}})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, "Café", result.Title)
+	require.NotNil(t, result.Content.Encoding)
+	assert.Equal(t, utils.EncodingInfo{Encoding: "windows-1252", Source: utils.EncodingSourceHeader}, *result.Content.Encoding)
+}
+
+func TestAnalyzeHTML_WithoutBaseURL(t *testing.T) {
+	service := newDefaultService(t)
+
+	// Call the AnalyzeHTML method with only relative links
+	result, err := service.AnalyzeHTML(context.Background(), services.HTMLInput{
+		Body: []byte(`<html><body><a href="/about">About</a></body></html>`),
+	}, services.AnalyzeOptions{Checks: []string{services.AnalyzerLinks}})
+
+	// Assertions
+	require.NoError(t, err)
+	require.Len(t, result.Links, 1)
+	assert.Equal(t, "/about", result.Links[0].URL)
+	assert.False(t, result.Links[0].Accessible)
+}
+
+func TestAnalyzeHTML_ResponseAnalyzerRequested(t *testing.T) {
+	service := newDefaultService(t)
+
+	// Call the AnalyzeHTML method with an analyzer that needs a response
+	_, err := service.AnalyzeHTML(context.Background(), services.HTMLInput{Body: []byte(`<html></html>`)},
+		services.AnalyzeOptions{Checks: []string{services.AnalyzerTitle, services.AnalyzerSecurity}})
+
+	// Assertions
+	assert.ErrorIs(t, err, services.ErrAnalyzerNeedsFetch)
+	assert.Contains(t, err.Error(), services.AnalyzerSecurity)
+}
+
+func TestAnalyzeHTML_XHTML(t *testing.T) {
+	service := newDefaultService(t)
+
+	// Call the AnalyzeHTML method with an XHTML document
+	result, err := service.AnalyzeHTML(context.Background(), services.HTMLInput{
+		Body:        []byte(`<?xml version="1.0"?><html xmlns="http://www.w3.org/1999/xhtml"><head><title>Email</title></head><body/></html>`),
+		ContentType: "application/xhtml+xml",
+	}, services.AnalyzeOptions{Checks: []string{services.AnalyzerTitle}})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.Equal(t, services.ParserXML, result.Content.Parser)
+	assert.Equal(t, "Email", result.Title)
+}
+
+func TestAnalyzeHTML_NotHTML(t *testing.T) {
+	service := newDefaultService(t)
+
+	// Call the AnalyzeHTML method with a JSON document
+	result, err := service.AnalyzeHTML(context.Background(), services.HTMLInput{Body: []byte(`{"page": 1}`), ContentType: "application/json"}, services.AnalyzeOptions{})
+
+	// Assertions
+	require.NoError(t, err)
+	assert.False(t, result.Content.IsHTML)
+	assert.Equal(t, "application/json", result.Content.ContentType)
+	assert.Empty(t, result.Analyzers)
+}
+
+func TestAnalyze_ReportsProgress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><body><a href="/a">A</a><a href="/b">B</a></body></html>`))
+	}))
+	defer server.Close()
+
+	service, err := services.NewAnalyzerService(&config.Config{}, newTestFetcher(t))
+	require.NoError(t, err)
+
+	var reports []services.Progress
+	opts := services.AnalyzeOptions{
+		Checks:     []string{services.AnalyzerTitle, services.AnalyzerLinks},
+		OnProgress: func(p services.Progress) { reports = append(reports, p) },
+	}
+
+	// Call the Analyze method
+	_, err = service.Analyze(context.Background(), server.URL, opts)
+
+	// Assertions
+	require.NoError(t, err)
+	require.NotEmpty(t, reports)
+	assert.Equal(t, services.StageFetching, reports[0].Stage)
+	assert.Equal(t, services.Progress{Stage: services.StageAnalyzing, AnalyzersDone: 2, AnalyzersTotal: 2, LinksChecked: 2, LinksTotal: 2}, reports[len(reports)-1])
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"github.com/uikee/web-analyzer-service/internal/utils"
+)
+
+// Names of the built-in analyzers
+const (
+	AnalyzerTitle          = "title"
+	AnalyzerHTMLVersion    = "html_version"
+	AnalyzerHeadings       = "headings"
+	AnalyzerLinks          = "links"
+	AnalyzerLoginForm      = "login_form"
+	AnalyzerForms          = "forms"
+	AnalyzerMetadata       = "metadata"
+	AnalyzerStructuredData = "structured_data"
+	AnalyzerAccessibility  = "accessibility"
+	AnalyzerResponse       = "response"
+	AnalyzerSecurity       = "security_headers"
+	AnalyzerRedirects      = "redirects"
+)
+
+// builtinAnalyzerVersion is the version reported by all built-in analyzers
+const builtinAnalyzerVersion = "1.0.0"
+
+// RegisterBuiltinAnalyzers adds the built-in analyzers to the registry
+func RegisterBuiltinAnalyzers(r *Registry, linkChecker *utils.LinkChecker) error {
+	builtins := []Analyzer{
+		NewAnalyzerFunc(AnalyzerTitle, builtinAnalyzerVersion, runTitle),
+		NewAnalyzerFunc(AnalyzerHTMLVersion, builtinAnalyzerVersion, runHTMLVersion),
+		NewAnalyzerFunc(AnalyzerHeadings, builtinAnalyzerVersion, runHeadings),
+		NewAnalyzerFunc(AnalyzerLinks, builtinAnalyzerVersion, linksRunner(linkChecker)),
+		NewAnalyzerFunc(AnalyzerLoginForm, builtinAnalyzerVersion, runLoginForm),
+		NewAnalyzerFunc(AnalyzerForms, builtinAnalyzerVersion, runForms),
+		NewAnalyzerFunc(AnalyzerMetadata, builtinAnalyzerVersion, runMetadata),
+		NewAnalyzerFunc(AnalyzerStructuredData, builtinAnalyzerVersion, runStructuredData),
+		NewAnalyzerFunc(AnalyzerAccessibility, builtinAnalyzerVersion, runAccessibility),
+		NewAnalyzerFunc(AnalyzerResponse, builtinAnalyzerVersion, runResponse),
+		NewAnalyzerFunc(AnalyzerSecurity, builtinAnalyzerVersion, runSecurityHeaders),
+		NewAnalyzerFunc(AnalyzerRedirects, builtinAnalyzerVersion, runRedirects),
+	}
+
+	for _, a := range builtins {
+		if err := r.Register(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TitleSection is the output of the title analyzer
+type TitleSection struct {
+	Title string
+}
+
+// Apply fills in the page title
+func (s TitleSection) Apply(result *AnalysisResult) {
+	result.Title = s.Title
+}
+
+// runTitle extracts the page title
+func runTitle(ctx context.Context, doc *utils.Document, meta PageMeta) (any, error) {
+	return TitleSection{Title: utils.ExtractTitle(doc)}, nil
+}
+
+// HTMLVersionSection is the output of the HTML version analyzer
+type HTMLVersionSection struct {
+	HTMLVersion string
+	Doctype     utils.DoctypeInfo
+}
+
+// Apply fills in the detected HTML version and DOCTYPE details
+func (s HTMLVersionSection) Apply(result *AnalysisResult) {
+	result.HTMLVersion = s.HTMLVersion
+	result.Doctype = s.Doctype
+}
+
+// runHTMLVersion detects the HTML version from the DOCTYPE
+func runHTMLVersion(ctx context.Context, doc *utils.Document, meta PageMeta) (any, error) {
+	doctype := utils.AnalyzeDoctype(doc)
+	return HTMLVersionSection{HTMLVersion: doctype.Version, Doctype: doctype}, nil
+}
+
+// HeadingsSection is the output of the headings analyzer
+type HeadingsSection struct {
+	Headings map[string]int
+	Outline  utils.HeadingOutline
+}
+
+// Apply fills in the heading counts and outline
+func (s HeadingsSection) Apply(result *AnalysisResult) {
+	result.Headings = s.Headings
+	result.HeadingOutline = s.Outline
+}
+
+// runHeadings counts the headings and builds their outline
+func runHeadings(ctx context.Context, doc *utils.Document, meta PageMeta) (any, error) {
+	return HeadingsSection{Headings: utils.CountHeadings(doc), Outline: utils.BuildHeadingOutline(doc)}, nil
+}
+
+// LinksSection is the output of the links analyzer
+type LinksSection struct {
+	Links []utils.LinkResult
+}
+
+// Apply fills in the link list and the derived counters
+func (s LinksSection) Apply(result *AnalysisResult) {
+	summary := utils.SummarizeLinks(s.Links)
+	result.InternalLinks = summary.Internal
+	result.ExternalLinks = summary.External
+	result.InaccessibleLinks = summary.Inaccessible
+	result.Links = s.Links
+}
+
+// linksRunner returns the links analyzer, which checks every link with linkChecker
+func linksRunner(linkChecker *utils.LinkChecker) func(ctx context.Context, doc *utils.Document, meta PageMeta) (any, error) {
+	return func(ctx context.Context, doc *utils.Document, meta PageMeta) (any, error) {
+		links, err := linkChecker.CheckLinksWithOptions(ctx, doc, meta.FinalURL, meta.LinkCheck)
+		if err != nil {
+			return nil, err
+		}
+		return LinksSection{Links: links}, nil
+	}
+}
+
+// LoginFormSection is the output of the login form analyzer
+type LoginFormSection struct {
+	HasLoginForm bool
+	Forms        []utils.FormClassification
+}
+
+// Apply fills in whether the page contains a login form and how each form was classified
+func (s LoginFormSection) Apply(result *AnalysisResult) {
+	result.HasLoginForm = s.HasLoginForm
+	result.FormClassifications = s.Forms
+}
+
+// runLoginForm classifies the forms and reports whether one of them is a login form
+func runLoginForm(ctx context.Context, doc *utils.Document, meta PageMeta) (any, error) {
+	forms := utils.ClassifyForms(doc)
+	return LoginFormSection{HasLoginForm: utils.HasLoginForm(forms), Forms: forms}, nil
+}
+
+// FormsSection is the output of the forms analyzer
+type FormsSection struct {
+	Forms []utils.FormInfo
+}
+
+// Apply fills in the forms inventory
+func (s FormsSection) Apply(result *AnalysisResult) {
+	result.Forms = s.Forms
+}
+
+// runForms inventories the forms, resolving their actions against the final URL
+func runForms(ctx context.Context, doc *utils.Document, meta PageMeta) (any, error) {
+	return FormsSection{Forms: utils.InventoryForms(doc, meta.FinalURL)}, nil
+}
+
+// MetadataSection is the output of the metadata analyzer
+type MetadataSection struct {
+	Metadata utils.Metadata
+}
+
+// Apply fills in the page metadata
+func (s MetadataSection) Apply(result *AnalysisResult) {
+	result.Metadata = s.Metadata
+}
+
+// runMetadata extracts the page metadata
+func runMetadata(ctx context.Context, doc *utils.Document, meta PageMeta) (any, error) {
+	return MetadataSection{Metadata: utils.ExtractMetadata(doc, meta.FinalURL, meta.Header)}, nil
+}
+
+// StructuredDataSection is the output of the structured data analyzer
+type StructuredDataSection struct {
+	StructuredData utils.StructuredData
+}
+
+// Apply fills in the structured data entities
+func (s StructuredDataSection) Apply(result *AnalysisResult) {
+	result.StructuredData = s.StructuredData
+}
+
+// runStructuredData extracts JSON-LD, Microdata and RDFa entities
+func runStructuredData(ctx context.Context, doc *utils.Document, meta PageMeta) (any, error) {
+	return StructuredDataSection{StructuredData: utils.ExtractStructuredData(doc)}, nil
+}
+
+// AccessibilitySection is the output of the accessibility analyzer
+type AccessibilitySection struct {
+	Report utils.AccessibilityReport
+}
+
+// Apply fills in the accessibility findings
+func (s AccessibilitySection) Apply(result *AnalysisResult) {
+	result.Accessibility = s.Report
+}
+
+// runAccessibility audits the page for accessibility issues
+func runAccessibility(ctx context.Context, doc *utils.Document, meta PageMeta) (any, error) {
+	return AccessibilitySection{Report: utils.AuditAccessibility(doc)}, nil
+}
+
+// ResponseSection is the output of the response analyzer
+type ResponseSection struct {
+	Response utils.ResponseInfo
+}
+
+// Apply fills in the response summary
+func (s ResponseSection) Apply(result *AnalysisResult) {
+	result.Response = s.Response
+}
+
+// runResponse describes the fetched response
+func runResponse(ctx context.Context, doc *utils.Document, meta PageMeta) (any, error) {
+	return ResponseSection{Response: utils.DescribeResponse(meta.StatusCode, meta.Proto, meta.Header, meta.BodySize, meta.Compression)}, nil
+}
+
+// SecurityHeadersSection is the output of the security headers analyzer
+type SecurityHeadersSection struct {
+	SecurityHeaders utils.SecurityHeaders
+}
+
+// Apply fills in the security header audit
+func (s SecurityHeadersSection) Apply(result *AnalysisResult) {
+	result.SecurityHeaders = s.SecurityHeaders
+}
+
+// runSecurityHeaders audits the security headers of the response
+func runSecurityHeaders(ctx context.Context, doc *utils.Document, meta PageMeta) (any, error) {
+	https := strings.HasPrefix(strings.ToLower(meta.FinalURL), "https://")
+	return SecurityHeadersSection{SecurityHeaders: utils.AuditSecurityHeaders(meta.Header, https)}, nil
+}
+
+// RedirectsSection is the output of the redirects analyzer
+type RedirectsSection struct {
+	Redirects utils.RedirectChain
+}
+
+// Apply fills in the redirect chain
+func (s RedirectsSection) Apply(result *AnalysisResult) {
+	result.Redirects = s.Redirects
+}
+
+// runRedirects analyzes the redirect chain that led to the page
+func runRedirects(ctx context.Context, doc *utils.Document, meta PageMeta) (any, error) {
+	return RedirectsSection{Redirects: utils.AnalyzeRedirects(meta.Hops)}, nil
+}
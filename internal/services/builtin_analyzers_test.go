@@ -0,0 +1,95 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uikee/web-analyzer-service/config"
+	"github.com/uikee/web-analyzer-service/internal/fetcher"
+	"github.com/uikee/web-analyzer-service/internal/services"
+	"github.com/uikee/web-analyzer-service/internal/utils"
+)
+
+// runBuiltin runs a single built-in analyzer against htmlContent and applies its section
+func runBuiltin(t *testing.T, name, htmlContent string) services.AnalysisResult {
+	okFetcher := fetcher.FetchFunc(func(ctx context.Context, req fetcher.Request) (*fetcher.Response, error) {
+		return &fetcher.Response{URL: req.URL, StatusCode: 200}, nil
+	})
+
+	registry := services.NewRegistry()
+	require.NoError(t, services.RegisterBuiltinAnalyzers(registry, utils.NewLinkChecker(okFetcher, config.LinkCheckConfig{})))
+
+	analyzer, ok := registry.Get(name)
+	require.True(t, ok, "built-in analyzer %q should be registered", name)
+
+	doc, err := utils.ParseDocument(htmlContent)
+	require.NoError(t, err)
+
+	section, err := analyzer.Run(context.Background(), doc, services.PageMeta{
+		URL:      "http://example.com",
+		FinalURL: "https://example.com/home",
+		Hops: []fetcher.Hop{
+			{URL: "http://example.com", StatusCode: 301, Location: "https://example.com/home"},
+			{URL: "https://example.com/home", StatusCode: 200},
+		},
+		StatusCode: 200,
+		Proto:      "HTTP/1.1",
+	})
+	require.NoError(t, err)
+
+	var result services.AnalysisResult
+	section.(services.Section).Apply(&result)
+	return result
+}
+
+func TestBuiltinAnalyzers(t *testing.T) {
+	page := `<!DOCTYPE html><html><head><title>Page</title></head><body>
+		<h1>One</h1><h2>Two</h2>
+		<a href="/inside">Inside</a><a href="https://elsewhere.org/">Elsewhere</a>
+		<form><input type="password"></form>
+	</body></html>`
+
+	assert.Equal(t, "Page", runBuiltin(t, services.AnalyzerTitle, page).Title)
+	version := runBuiltin(t, services.AnalyzerHTMLVersion, page)
+	assert.Equal(t, "HTML5", version.HTMLVersion)
+	assert.Equal(t, utils.RenderingModeNoQuirks, version.Doctype.RenderingMode)
+	headings := runBuiltin(t, services.AnalyzerHeadings, page)
+	assert.Equal(t, map[string]int{"h1": 1, "h2": 1}, headings.Headings)
+	assert.Len(t, headings.HeadingOutline.Headings, 2)
+	loginForm := runBuiltin(t, services.AnalyzerLoginForm, page)
+	assert.True(t, loginForm.HasLoginForm)
+	assert.Len(t, loginForm.FormClassifications, 1)
+	assert.Equal(t, utils.FormTypeLogin, loginForm.FormClassifications[0].Type)
+
+	assert.Contains(t, runBuiltin(t, services.AnalyzerMetadata, page).Metadata.Findings,
+		utils.Finding{Rule: utils.RuleHTMLLangMissing, Severity: utils.SeverityMedium, Message: "The html element has no lang attribute"})
+
+	assert.Empty(t, runBuiltin(t, services.AnalyzerStructuredData, page).StructuredData.Entities)
+
+	accessibility := runBuiltin(t, services.AnalyzerAccessibility, page).Accessibility
+	assert.Equal(t, map[string]int{utils.SeverityHigh: 1, utils.SeverityMedium: 1}, accessibility.Counts, "Missing lang and an unlabeled password field")
+
+	forms := runBuiltin(t, services.AnalyzerForms, page).Forms
+	assert.Len(t, forms, 1)
+	assert.Equal(t, "https://example.com/home", forms[0].ResolvedAction, "Forms resolve against the final URL")
+
+	links := runBuiltin(t, services.AnalyzerLinks, page)
+	assert.Equal(t, 1, links.InternalLinks)
+	assert.Equal(t, 1, links.ExternalLinks)
+	assert.Equal(t, 0, links.InaccessibleLinks)
+	assert.Len(t, links.Links, 2)
+	assert.Equal(t, "https://example.com/inside", links.Links[0].URL, "Links resolve against the final URL")
+
+	response := runBuiltin(t, services.AnalyzerResponse, page).Response
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "HTTP/1.1", response.Protocol)
+
+	security := runBuiltin(t, services.AnalyzerSecurity, page).SecurityHeaders
+	assert.Equal(t, utils.VerdictFail, security.Headers[0].Verdict, "HSTS is required on the HTTPS final URL")
+
+	redirects := runBuiltin(t, services.AnalyzerRedirects, page).Redirects
+	assert.Equal(t, 1, redirects.Redirects)
+	assert.Equal(t, "https://example.com/home", redirects.FinalURL)
+}
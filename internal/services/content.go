@@ -0,0 +1,104 @@
+package services
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/uikee/web-analyzer-service/internal/fetcher"
+	"github.com/uikee/web-analyzer-service/internal/utils"
+)
+
+// Media types the service can analyze
+const (
+	MediaTypeHTML  = "text/html"
+	MediaTypeXHTML = "application/xhtml+xml"
+)
+
+// Parsers reported in ContentInfo.Parser
+const (
+	ParserHTML = "html"
+	ParserXML  = "xml"
+)
+
+// Messages reported in ContentInfo.Message when there is no page to analyze
+const (
+	notHTMLMessage      = "not an HTML document"
+	redirectLoopMessage = "URL redirects in a loop"
+)
+
+// htmlContentTypes are the media types accepted for analyzed pages
+var htmlContentTypes = []string{MediaTypeHTML, MediaTypeXHTML}
+
+// ContentInfo describes the kind of document the URL served
+type ContentInfo struct {
+	// IsHTML is false for documents that are not analyzed, e.g. JSON, plain text
+	// or images, and for URLs that redirect in a loop. Message says which; all other
+	// sections of the result except the redirect chain are then empty.
+	IsHTML      bool   `json:"is_html"`
+	ContentType string `json:"content_type"`
+
+	// Parser is how an HTML document was parsed: "html", or "xml" for XHTML
+	Parser string `json:"parser,omitempty"`
+
+	// Encoding is the character encoding an HTML document was decoded from, which
+	// may differ from the charset it declares
+	Encoding *utils.EncodingInfo `json:"encoding,omitempty"`
+
+	// Size is the body size in bytes, or -1 if it is unknown
+	Size    int64  `json:"size"`
+	Message string `json:"message,omitempty"`
+}
+
+// notHTML is the result for a document the analyzers cannot handle
+func notHTML(contentType string, size int64) AnalysisResult {
+	return AnalysisResult{
+		Content: ContentInfo{
+			ContentType: contentType,
+			Size:        size,
+			Message:     notHTMLMessage,
+		},
+		Analyzers: []AnalyzerReport{},
+	}
+}
+
+// notHTMLError converts a content type rejection into the result for a non-HTML document
+func notHTMLError(err *fetcher.ContentTypeError) AnalysisResult {
+	contentType := err.ContentType
+	if mediaType, _, parseErr := mime.ParseMediaType(contentType); parseErr == nil {
+		contentType = mediaType
+	}
+	return notHTML(contentType, err.ContentLength)
+}
+
+// sniffMediaType returns the media type of a page. Pages without a Content-Type
+// are sniffed from their body.
+func sniffMediaType(contentType string, body []byte) string {
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType
+	}
+	return mediaType
+}
+
+// parseContent transcodes and parses a page according to its media type
+func parseContent(body []byte, contentType, mediaType string) (*utils.Document, utils.EncodingInfo, error) {
+	if mediaType == MediaTypeXHTML {
+		content, encoding, err := utils.DecodeXML(body, contentType)
+		if err != nil {
+			return nil, encoding, err
+		}
+		doc, err := utils.ParseXHTML(content)
+		return doc, encoding, err
+	}
+
+	content, encoding, err := utils.DecodeHTML(body, contentType)
+	if err != nil {
+		return nil, encoding, err
+	}
+	doc, err := utils.ParseDocument(content)
+	return doc, encoding, err
+}
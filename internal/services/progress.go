@@ -0,0 +1,47 @@
+package services
+
+import "sync"
+
+// Stages of an analysis reported in Progress
+const (
+	StageFetching  = "fetching"
+	StageAnalyzing = "analyzing"
+)
+
+// Progress reports how far an analysis has come. Link counts are filled in
+// once the links analyzer has collected the page's links.
+type Progress struct {
+	Stage          string `json:"stage"`
+	AnalyzersDone  int    `json:"analyzers_done"`
+	AnalyzersTotal int    `json:"analyzers_total"`
+	LinksChecked   int    `json:"links_checked"`
+	LinksTotal     int    `json:"links_total"`
+}
+
+// progressTracker accumulates the progress of one analysis and passes a copy of
+// every update to a callback. A nil tracker ignores updates.
+type progressTracker struct {
+	mu       sync.Mutex
+	progress Progress
+	report   func(Progress)
+}
+
+// newProgressTracker returns a tracker reporting to report, or nil if report is nil
+func newProgressTracker(report func(Progress)) *progressTracker {
+	if report == nil {
+		return nil
+	}
+	return &progressTracker{report: report}
+}
+
+// update applies change and reports the result. Reports are serialized, so the
+// callback never runs concurrently with itself.
+func (t *progressTracker) update(change func(p *Progress)) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	change(&t.progress)
+	t.report(t.progress)
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	// ErrInvalidAnalyzer indicates that an analyzer without a name was registered
+	ErrInvalidAnalyzer = errors.New("analyzer must have a name")
+
+	// ErrDuplicateAnalyzer indicates that an analyzer with the same name is already registered
+	ErrDuplicateAnalyzer = errors.New("analyzer already registered")
+)
+
+// Registry holds the analyzers run by the service, in registration order
+type Registry struct {
+	mu        sync.RWMutex
+	analyzers []Analyzer
+	byName    map[string]Analyzer
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{byName: make(map[string]Analyzer)}
+}
+
+// Register adds an analyzer. Names must be unique.
+func (r *Registry) Register(a Analyzer) error {
+	if a == nil || a.Name() == "" {
+		return ErrInvalidAnalyzer
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.byName[a.Name()]; exists {
+		return fmt.Errorf("%w: %s", ErrDuplicateAnalyzer, a.Name())
+	}
+	r.analyzers = append(r.analyzers, a)
+	r.byName[a.Name()] = a
+	return nil
+}
+
+// Get returns the analyzer registered under name
+func (r *Registry) Get(name string) (Analyzer, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	a, ok := r.byName[name]
+	return a, ok
+}
+
+// Analyzers returns all registered analyzers in registration order
+func (r *Registry) Analyzers() []Analyzer {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]Analyzer(nil), r.analyzers...)
+}
+
+// Names returns the names of all registered analyzers in registration order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.analyzers))
+	for _, a := range r.analyzers {
+		names = append(names, a.Name())
+	}
+	return names
+}
@@ -0,0 +1,39 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uikee/web-analyzer-service/internal/services"
+)
+
+func TestRegistry_RegisterAndGet(t *testing.T) {
+	registry := services.NewRegistry()
+
+	assert.NoError(t, registry.Register(constant("first", nil)))
+	assert.NoError(t, registry.Register(constant("second", nil)))
+
+	a, ok := registry.Get("second")
+	assert.True(t, ok)
+	assert.Equal(t, "second", a.Name())
+
+	_, ok = registry.Get("missing")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"first", "second"}, registry.Names())
+	assert.Len(t, registry.Analyzers(), 2)
+}
+
+func TestRegistry_RejectsDuplicates(t *testing.T) {
+	registry := services.NewRegistry()
+
+	assert.NoError(t, registry.Register(constant("title", nil)))
+	assert.ErrorIs(t, registry.Register(constant("title", nil)), services.ErrDuplicateAnalyzer)
+}
+
+func TestRegistry_RejectsUnnamedAnalyzer(t *testing.T) {
+	registry := services.NewRegistry()
+
+	assert.ErrorIs(t, registry.Register(constant("", nil)), services.ErrInvalidAnalyzer)
+	assert.ErrorIs(t, registry.Register(nil), services.ErrInvalidAnalyzer)
+}
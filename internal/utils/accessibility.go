@@ -0,0 +1,302 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Accessibility rules, named after the WCAG checks they implement
+const (
+	RuleImageAlt      = "image-alt"
+	RuleLabel         = "label"
+	RuleButtonName    = "button-name"
+	RuleLinkName      = "link-name"
+	RuleHTMLHasLang   = "html-has-lang"
+	RuleDuplicateID   = "duplicate-id"
+	RuleARIARole      = "aria-valid-role"
+	RuleARIAAttribute = "aria-valid-attr"
+	RuleTableHeaders  = "table-headers"
+	RuleTabindex      = "tabindex"
+)
+
+// validRoles are the WAI-ARIA 1.2 roles; DPUB ("doc-") and graphics roles are checked by prefix
+var validRoles = setOf(
+	"alert", "alertdialog", "application", "article", "banner", "blockquote", "button", "caption",
+	"cell", "checkbox", "code", "columnheader", "combobox", "complementary", "contentinfo",
+	"definition", "deletion", "dialog", "directory", "document", "emphasis", "feed", "figure",
+	"form", "generic", "grid", "gridcell", "group", "heading", "img", "insertion", "link", "list",
+	"listbox", "listitem", "log", "main", "mark", "marquee", "math", "menu", "menubar", "menuitem",
+	"menuitemcheckbox", "menuitemradio", "meter", "navigation", "none", "note", "option",
+	"paragraph", "presentation", "progressbar", "radio", "radiogroup", "region", "row", "rowgroup",
+	"rowheader", "scrollbar", "search", "searchbox", "separator", "slider", "spinbutton", "status",
+	"strong", "subscript", "superscript", "switch", "tab", "table", "tablist", "tabpanel", "term",
+	"textbox", "time", "timer", "toolbar", "tooltip", "tree", "treegrid", "treeitem",
+)
+
+// validARIAAttributes are the WAI-ARIA 1.2 states and properties
+var validARIAAttributes = setOf(
+	"aria-activedescendant", "aria-atomic", "aria-autocomplete", "aria-braillelabel",
+	"aria-brailleroledescription", "aria-busy", "aria-checked", "aria-colcount", "aria-colindex",
+	"aria-colindextext", "aria-colspan", "aria-controls", "aria-current", "aria-describedby",
+	"aria-description", "aria-details", "aria-disabled", "aria-dropeffect", "aria-errormessage",
+	"aria-expanded", "aria-flowto", "aria-grabbed", "aria-haspopup", "aria-hidden", "aria-invalid",
+	"aria-keyshortcuts", "aria-label", "aria-labelledby", "aria-level", "aria-live", "aria-modal",
+	"aria-multiline", "aria-multiselectable", "aria-orientation", "aria-owns", "aria-placeholder",
+	"aria-posinset", "aria-pressed", "aria-readonly", "aria-relevant", "aria-required",
+	"aria-roledescription", "aria-rowcount", "aria-rowindex", "aria-rowindextext", "aria-rowspan",
+	"aria-selected", "aria-setsize", "aria-sort", "aria-valuemax", "aria-valuemin", "aria-valuenow",
+	"aria-valuetext",
+)
+
+// unlabeledInputTypes are input types that need no label
+var unlabeledInputTypes = setOf("hidden", "submit", "reset", "button", "image")
+
+// AccessibilityReport is the result of the accessibility audit
+type AccessibilityReport struct {
+	Findings []Finding `json:"findings"`
+
+	// Counts is the number of findings per severity
+	Counts map[string]int `json:"counts"`
+}
+
+// accessibilityAudit holds the page-wide lookups the checks need
+type accessibilityAudit struct {
+	ids      map[string]*html.Node
+	labelFor map[string]bool
+	findings []Finding
+}
+
+// AuditAccessibility checks the document against common WCAG failures: images
+// without alt text, unlabeled controls, nameless buttons and links, a missing lang,
+// duplicate ids, invalid ARIA, tables without headers and positive tabindex
+func AuditAccessibility(doc *Document) AccessibilityReport {
+	audit := &accessibilityAudit{ids: map[string]*html.Node{}, labelFor: map[string]bool{}}
+
+	// First pass: ids and explicit labels, so later checks can resolve references
+	doc.Walk(func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		if id, ok := attr(n, "id"); ok && id != "" {
+			if _, seen := audit.ids[id]; seen {
+				// The id itself is ambiguous, so the path must not rely on it
+				audit.findings = append(audit.findings, Finding{
+					Rule:     RuleDuplicateID,
+					Severity: SeverityMedium,
+					Message:  fmt.Sprintf("id %q is used more than once", id),
+					Path:     cssPath(n, false),
+				})
+			} else {
+				audit.ids[id] = n
+			}
+		}
+		if n.Data == "label" {
+			if target, ok := attr(n, "for"); ok {
+				audit.labelFor[target] = true
+			}
+		}
+		return true
+	})
+
+	if root := doc.Find("html"); root != nil {
+		if lang, _ := attr(root, "lang"); strings.TrimSpace(lang) == "" {
+			audit.add(RuleHTMLHasLang, SeverityMedium, "The html element has no lang attribute", root)
+		}
+	}
+
+	doc.Walk(func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		audit.checkARIA(n)
+		audit.checkTabindex(n)
+
+		// Content hidden from assistive technology needs no accessible name
+		if ariaHidden(n) {
+			return true
+		}
+
+		switch n.Data {
+		case "img":
+			audit.checkImage(n)
+		case "input", "select", "textarea":
+			audit.checkControl(n)
+		case "button":
+			if audit.accessibleName(n) == "" {
+				audit.add(RuleButtonName, SeverityHigh, "Button has no accessible name", n)
+			}
+		case "a":
+			if _, ok := attr(n, "href"); ok && audit.accessibleName(n) == "" {
+				audit.add(RuleLinkName, SeverityHigh, "Link has no accessible name", n)
+			}
+		case "table":
+			audit.checkTable(n)
+		}
+		return true
+	})
+
+	report := AccessibilityReport{Findings: audit.findings, Counts: map[string]int{}}
+	if report.Findings == nil {
+		report.Findings = []Finding{}
+	}
+	for _, finding := range report.Findings {
+		report.Counts[finding.Severity]++
+	}
+	return report
+}
+
+// add records a finding for the element n
+func (a *accessibilityAudit) add(rule, severity, message string, n *html.Node) {
+	a.findings = append(a.findings, Finding{Rule: rule, Severity: severity, Message: message, Path: CSSPath(n)})
+}
+
+// checkImage requires an alt attribute; an empty alt marks a decorative image
+func (a *accessibilityAudit) checkImage(n *html.Node) {
+	if _, ok := attr(n, "alt"); ok {
+		return
+	}
+	if role, _ := attr(n, "role"); role == "presentation" || role == "none" {
+		return
+	}
+	if a.ariaName(n) != "" {
+		return
+	}
+	a.add(RuleImageAlt, SeverityHigh, "Image has no alt attribute", n)
+}
+
+// checkControl requires form controls to have a label
+func (a *accessibilityAudit) checkControl(n *html.Node) {
+	inputType, _ := attr(n, "type")
+	inputType = strings.ToLower(strings.TrimSpace(inputType))
+
+	if n.Data == "input" {
+		switch {
+		case inputType == "image":
+			if alt, _ := attr(n, "alt"); strings.TrimSpace(alt) == "" && a.ariaName(n) == "" {
+				a.add(RuleImageAlt, SeverityHigh, "Image button has no alt text", n)
+			}
+			return
+		case inputType == "button":
+			if value, _ := attr(n, "value"); strings.TrimSpace(value) == "" && a.ariaName(n) == "" {
+				a.add(RuleButtonName, SeverityHigh, "Button has no accessible name", n)
+			}
+			return
+		case unlabeledInputTypes[inputType]:
+			return
+		}
+	}
+
+	if id, ok := attr(n, "id"); ok && a.labelFor[id] {
+		return
+	}
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.Data == "label" {
+			return
+		}
+	}
+	if a.ariaName(n) != "" {
+		return
+	}
+	if title, _ := attr(n, "title"); strings.TrimSpace(title) != "" {
+		return
+	}
+	a.add(RuleLabel, SeverityHigh, fmt.Sprintf("Form control <%s> has no label", n.Data), n)
+}
+
+// checkTable requires data tables to have header cells
+func (a *accessibilityAudit) checkTable(n *html.Node) {
+	if role, _ := attr(n, "role"); role == "presentation" || role == "none" {
+		return
+	}
+
+	rows, headers := 0, 0
+	walkNode(n, func(c *html.Node) bool {
+		if c.Type != html.ElementNode {
+			return true
+		}
+		// Nested tables are checked on their own
+		if c != n && c.Data == "table" {
+			return false
+		}
+		switch c.Data {
+		case "tr":
+			rows++
+		case "th":
+			headers++
+		}
+		return true
+	})
+
+	// Single-row tables are almost always used for layout
+	if rows > 1 && headers == 0 {
+		a.add(RuleTableHeaders, SeverityMedium, "Data table has no header cells", n)
+	}
+}
+
+// checkARIA validates role values and aria-* attribute names
+func (a *accessibilityAudit) checkARIA(n *html.Node) {
+	if role, ok := attr(n, "role"); ok {
+		for _, token := range strings.Fields(strings.ToLower(role)) {
+			if !validRoles[token] && !strings.HasPrefix(token, "doc-") && !strings.HasPrefix(token, "graphics-") {
+				a.add(RuleARIARole, SeverityMedium, fmt.Sprintf("%q is not a valid ARIA role", token), n)
+			}
+		}
+	}
+	for _, attribute := range n.Attr {
+		if strings.HasPrefix(attribute.Key, "aria-") && !validARIAAttributes[attribute.Key] {
+			a.add(RuleARIAAttribute, SeverityMedium, fmt.Sprintf("%q is not a valid ARIA attribute", attribute.Key), n)
+		}
+	}
+}
+
+// checkTabindex flags positive tabindex values, which break the natural focus order
+func (a *accessibilityAudit) checkTabindex(n *html.Node) {
+	value, ok := attr(n, "tabindex")
+	if !ok {
+		return
+	}
+	if tabindex, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && tabindex > 0 {
+		a.add(RuleTabindex, SeverityLow, fmt.Sprintf("tabindex=%d changes the natural focus order", tabindex), n)
+	}
+}
+
+// accessibleName approximates the accessible name of an element from ARIA, its content and title
+func (a *accessibilityAudit) accessibleName(n *html.Node) string {
+	if name := a.ariaName(n); name != "" {
+		return name
+	}
+	if text := textAlternative(n); text != "" {
+		return text
+	}
+	title, _ := attr(n, "title")
+	return strings.TrimSpace(title)
+}
+
+// ariaName returns the name given by aria-labelledby or aria-label
+func (a *accessibilityAudit) ariaName(n *html.Node) string {
+	if labelledBy, ok := attr(n, "aria-labelledby"); ok {
+		var parts []string
+		for _, id := range strings.Fields(labelledBy) {
+			if target, ok := a.ids[id]; ok {
+				parts = append(parts, textAlternative(target))
+			}
+		}
+		if name := strings.TrimSpace(strings.Join(parts, " ")); name != "" {
+			return name
+		}
+	}
+	label, _ := attr(n, "aria-label")
+	return strings.TrimSpace(label)
+}
+
+// setOf builds a lookup set from values
+func setOf(values ...string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
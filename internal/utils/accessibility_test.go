@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditAccessibility_AccessiblePage(t *testing.T) {
+	page := `<html lang="en"><body>
+		<img src="logo.png" alt="Company logo">
+		<img src="divider.png" alt="">
+		<label for="email">Email</label><input id="email" type="email">
+		<label>Name <input name="name"></label>
+		<input type="search" aria-label="Search">
+		<input type="hidden" name="token">
+		<input type="submit">
+		<button><img src="close.png" alt="Close"></button>
+		<span id="more-label">Read more</span><a href="/more" aria-labelledby="more-label"></a>
+		<div role="navigation" aria-expanded="false" tabindex="0"></div>
+		<table><tr><th>Name</th></tr><tr><td>Ada</td></tr></table>
+		<table role="presentation"><tr><td>a</td></tr><tr><td>b</td></tr></table>
+	</body></html>`
+
+	report := AuditAccessibility(mustParse(t, page))
+
+	assert.Empty(t, report.Findings)
+	assert.Empty(t, report.Counts)
+}
+
+func TestAuditAccessibility_Findings(t *testing.T) {
+	page := `<html><body>
+		<img src="chart.png">
+		<input type="text" name="q" placeholder="Search">
+		<button></button>
+		<a href="/next"><i class="icon"></i></a>
+		<p id="dup">One</p><p id="dup">Two</p>
+		<div role="buton"></div>
+		<span aria-lable="typo"></span>
+		<table><tr><td>1</td></tr><tr><td>2</td></tr></table>
+		<a href="/first" tabindex="3">First</a>
+		<div aria-hidden="true"><img src="decor.png"></div>
+	</body></html>`
+
+	report := AuditAccessibility(mustParse(t, page))
+
+	assert.Equal(t, []string{
+		RuleDuplicateID,
+		RuleHTMLHasLang,
+		RuleImageAlt,
+		RuleLabel,
+		RuleButtonName,
+		RuleLinkName,
+		RuleARIARole,
+		RuleARIAAttribute,
+		RuleTableHeaders,
+		RuleTabindex,
+	}, findingRules(report.Findings))
+	assert.Equal(t, "html > body > p:nth-of-type(2)", report.Findings[0].Path)
+	assert.Equal(t, "html > body > img", report.Findings[2].Path)
+	assert.Equal(t, map[string]int{SeverityHigh: 4, SeverityMedium: 5, SeverityLow: 1}, report.Counts)
+}
+
+func TestAuditAccessibility_InputButtons(t *testing.T) {
+	page := `<html lang="en"><body>
+		<input type="image" src="go.png">
+		<input type="button">
+		<input type="button" value="Go">
+	</body></html>`
+
+	report := AuditAccessibility(mustParse(t, page))
+
+	assert.Equal(t, []string{RuleImageAlt, RuleButtonName}, findingRules(report.Findings))
+}
@@ -0,0 +1,202 @@
+package utils
+
+import (
+	"bytes"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/transform"
+)
+
+// Where the character encoding of a page was found, in the order the HTML
+// encoding sniffing algorithm consults them
+const (
+	EncodingSourceBOM       = "bom"
+	EncodingSourceHeader    = "header"
+	EncodingSourceMeta      = "meta"
+	EncodingSourceHeuristic = "heuristic"
+
+	// XML documents are sniffed from their declaration and default to UTF-8
+	EncodingSourceXMLDeclaration = "xml-declaration"
+	EncodingSourceDefault        = "default"
+)
+
+// prescanBytes is how much of the body is searched for a <meta> charset declaration
+const prescanBytes = 1024
+
+// defaultEncoding is assumed for legacy pages that declare nothing and are not valid UTF-8
+const defaultEncoding = "windows-1252"
+
+// byteOrderMarks maps byte order marks to the encoding they announce
+var byteOrderMarks = []struct {
+	bom      []byte
+	encoding string
+}{
+	{[]byte{0xEF, 0xBB, 0xBF}, "utf-8"},
+	{[]byte{0xFE, 0xFF}, "utf-16be"},
+	{[]byte{0xFF, 0xFE}, "utf-16le"},
+}
+
+// xmlDeclarationEncoding matches the encoding declared by an XML declaration
+var xmlDeclarationEncoding = regexp.MustCompile(`^<\?xml\s[^>]*?encoding\s*=\s*["']([^"']+)["']`)
+
+// EncodingInfo describes the character encoding a page was decoded from
+type EncodingInfo struct {
+	// Encoding is the canonical WHATWG name of the encoding, e.g. "shift_jis"
+	Encoding string `json:"encoding"`
+	Source   string `json:"source"`
+}
+
+// DetectEncoding determines the character encoding of an HTML body following the
+// HTML encoding sniffing algorithm: a byte order mark wins, then the charset of
+// the Content-Type header, then a <meta> declaration in the first 1024 bytes.
+// Pages declaring nothing are treated as UTF-8 if they are valid UTF-8, ignoring a
+// character cut off at the end, and as windows-1252 otherwise.
+func DetectEncoding(body []byte, contentType string) EncodingInfo {
+	if info, ok := declaredEncoding(body, contentType); ok {
+		return info
+	}
+
+	if name := prescanCharset(body[:min(len(body), prescanBytes)]); name != "" {
+		return EncodingInfo{Encoding: name, Source: EncodingSourceMeta}
+	}
+
+	if utf8.Valid(trimIncompleteRune(body)) {
+		return EncodingInfo{Encoding: "utf-8", Source: EncodingSourceHeuristic}
+	}
+	return EncodingInfo{Encoding: defaultEncoding, Source: EncodingSourceHeuristic}
+}
+
+// trimIncompleteRune drops a UTF-8 sequence cut off at the end of body, as left
+// behind when a response is truncated in the middle of a character
+func trimIncompleteRune(body []byte) []byte {
+	for i := 1; i < utf8.UTFMax && i <= len(body); i++ {
+		if tail := body[len(body)-i:]; utf8.RuneStart(tail[0]) {
+			if !utf8.FullRune(tail) {
+				return body[:len(body)-i]
+			}
+			break
+		}
+	}
+	return body
+}
+
+// DetectXMLEncoding determines the character encoding of an XML document: a byte
+// order mark wins, then the charset of the Content-Type header, then the encoding
+// in the XML declaration. XML documents declaring nothing are UTF-8.
+func DetectXMLEncoding(body []byte, contentType string) EncodingInfo {
+	if info, ok := declaredEncoding(body, contentType); ok {
+		return info
+	}
+
+	if match := xmlDeclarationEncoding.FindSubmatch(body[:min(len(body), prescanBytes)]); match != nil {
+		if _, name := charset.Lookup(string(match[1])); name != "" {
+			return EncodingInfo{Encoding: name, Source: EncodingSourceXMLDeclaration}
+		}
+	}
+	return EncodingInfo{Encoding: "utf-8", Source: EncodingSourceDefault}
+}
+
+// declaredEncoding returns the encoding announced by a byte order mark or the Content-Type header
+func declaredEncoding(body []byte, contentType string) (EncodingInfo, bool) {
+	for _, mark := range byteOrderMarks {
+		if bytes.HasPrefix(body, mark.bom) {
+			return EncodingInfo{Encoding: mark.encoding, Source: EncodingSourceBOM}, true
+		}
+	}
+
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		if _, name := charset.Lookup(params["charset"]); name != "" {
+			return EncodingInfo{Encoding: name, Source: EncodingSourceHeader}, true
+		}
+	}
+	return EncodingInfo{}, false
+}
+
+// DecodeHTML transcodes an HTML body to UTF-8 using the detected encoding.
+// Byte sequences that are invalid in that encoding become U+FFFD.
+func DecodeHTML(body []byte, contentType string) (string, EncodingInfo, error) {
+	return decode(body, DetectEncoding(body, contentType))
+}
+
+// DecodeXML transcodes an XML body to UTF-8 using the detected encoding
+func DecodeXML(body []byte, contentType string) (string, EncodingInfo, error) {
+	return decode(body, DetectXMLEncoding(body, contentType))
+}
+
+// decode transcodes body from the encoding described by info to UTF-8
+func decode(body []byte, info EncodingInfo) (string, EncodingInfo, error) {
+	if info.Source == EncodingSourceBOM {
+		body = body[len(bomFor(info.Encoding)):]
+	}
+
+	enc, _ := charset.Lookup(info.Encoding)
+	decoded, _, err := transform.Bytes(enc.NewDecoder(), body)
+	if err != nil {
+		return "", info, err
+	}
+	return string(decoded), info, nil
+}
+
+// bomFor returns the byte order mark of the given encoding
+func bomFor(encoding string) []byte {
+	for _, mark := range byteOrderMarks {
+		if mark.encoding == encoding {
+			return mark.bom
+		}
+	}
+	return nil
+}
+
+// prescanCharset looks for a charset declared by <meta charset> or
+// <meta http-equiv="Content-Type"> and returns its canonical name
+func prescanCharset(content []byte) string {
+	z := html.NewTokenizer(bytes.NewReader(content))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tag, hasAttr := z.TagName()
+			if string(tag) != "meta" {
+				continue
+			}
+
+			var label, contentAttr string
+			var contentType bool
+			for hasAttr {
+				var key, value []byte
+				key, value, hasAttr = z.TagAttr()
+				switch string(key) {
+				case "charset":
+					label = string(value)
+				case "content":
+					contentAttr = string(value)
+				case "http-equiv":
+					contentType = strings.EqualFold(string(value), "content-type")
+				}
+			}
+			if label == "" && contentType {
+				if _, params, err := mime.ParseMediaType(contentAttr); err == nil {
+					label = params["charset"]
+				}
+			}
+
+			_, name := charset.Lookup(label)
+			switch {
+			case name == "":
+				continue
+			case strings.HasPrefix(name, "utf-16"):
+				// A page that could read its own <meta> as ASCII cannot be UTF-16
+				return "utf-8"
+			case name == "x-user-defined":
+				return defaultEncoding
+			}
+			return name
+		}
+	}
+}
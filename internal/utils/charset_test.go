@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// encode converts UTF-8 test content to the given encoding
+func encode(t *testing.T, e encoding.Encoding, content string) []byte {
+	t.Helper()
+	encoded, err := e.NewEncoder().Bytes([]byte(content))
+	require.NoError(t, err)
+	return encoded
+}
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		expected    EncodingInfo
+	}{
+		{
+			name:        "UTF-8 byte order mark beats the header",
+			body:        append([]byte{0xEF, 0xBB, 0xBF}, "<p>x</p>"...),
+			contentType: "text/html; charset=iso-8859-1",
+			expected:    EncodingInfo{Encoding: "utf-8", Source: EncodingSourceBOM},
+		},
+		{
+			name:     "UTF-16LE byte order mark",
+			body:     []byte{0xFF, 0xFE, '<', 0},
+			expected: EncodingInfo{Encoding: "utf-16le", Source: EncodingSourceBOM},
+		},
+		{
+			name:        "Header charset beats meta",
+			body:        []byte(`<meta charset="shift_jis">`),
+			contentType: "text/html; charset=GBK",
+			expected:    EncodingInfo{Encoding: "gbk", Source: EncodingSourceHeader},
+		},
+		{
+			name:        "Labels are normalized",
+			body:        []byte(`<p>x</p>`),
+			contentType: "text/html; charset=latin1",
+			expected:    EncodingInfo{Encoding: "windows-1252", Source: EncodingSourceHeader},
+		},
+		{
+			name:        "Unknown header charset falls through to meta",
+			body:        []byte(`<head><meta charset="Shift_JIS"></head>`),
+			contentType: "text/html; charset=bogus",
+			expected:    EncodingInfo{Encoding: "shift_jis", Source: EncodingSourceMeta},
+		},
+		{
+			name:     "Meta http-equiv",
+			body:     []byte(`<meta http-equiv="Content-Type" content="text/html; charset=gb2312">`),
+			expected: EncodingInfo{Encoding: "gbk", Source: EncodingSourceMeta},
+		},
+		{
+			name:     "Meta declaring UTF-16 means UTF-8",
+			body:     []byte(`<meta charset="utf-16">`),
+			expected: EncodingInfo{Encoding: "utf-8", Source: EncodingSourceMeta},
+		},
+		{
+			name:     "Undeclared valid UTF-8",
+			body:     []byte("<p>café</p>"),
+			expected: EncodingInfo{Encoding: "utf-8", Source: EncodingSourceHeuristic},
+		},
+		{
+			name:     "Undeclared UTF-8 truncated mid-character",
+			body:     []byte("<p>café \xe2\x82"),
+			expected: EncodingInfo{Encoding: "utf-8", Source: EncodingSourceHeuristic},
+		},
+		{
+			name:     "Undeclared legacy bytes",
+			body:     []byte("<p>caf\xe9</p>"),
+			expected: EncodingInfo{Encoding: "windows-1252", Source: EncodingSourceHeuristic},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DetectEncoding(tt.body, tt.contentType))
+		})
+	}
+}
+
+func TestDetectXMLEncoding(t *testing.T) {
+	assert.Equal(t, EncodingInfo{Encoding: "windows-1252", Source: EncodingSourceXMLDeclaration},
+		DetectXMLEncoding([]byte(`<?xml version="1.0" encoding='ISO-8859-1'?><html/>`), ""))
+	assert.Equal(t, EncodingInfo{Encoding: "euc-jp", Source: EncodingSourceHeader},
+		DetectXMLEncoding([]byte(`<?xml version="1.0" encoding="ISO-8859-1"?><html/>`), "application/xhtml+xml; charset=EUC-JP"))
+	assert.Equal(t, EncodingInfo{Encoding: "utf-8", Source: EncodingSourceDefault},
+		DetectXMLEncoding([]byte("<html><meta charset=\"shift_jis\"/>caf\xe9</html>"), ""), "XML ignores <meta> and defaults to UTF-8")
+}
+
+func TestDetectEncoding_MetaBeyondPrescanIsIgnored(t *testing.T) {
+	body := []byte("<!--" + string(make([]byte, prescanBytes)) + `--><meta charset="shift_jis">`)
+
+	assert.Equal(t, EncodingSourceHeuristic, DetectEncoding(body, "").Source)
+}
+
+func TestDecodeHTML(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		contentType string
+		encoding    string
+		title       string
+	}{
+		{
+			name:     "Shift_JIS",
+			body:     encode(t, japanese.ShiftJIS, `<meta charset="shift_jis"><title>日本語のページ</title>`),
+			encoding: "shift_jis",
+			title:    "日本語のページ",
+		},
+		{
+			name:        "GBK",
+			body:        encode(t, simplifiedchinese.GBK, `<title>中文网页</title>`),
+			contentType: "text/html; charset=gbk",
+			encoding:    "gbk",
+			title:       "中文网页",
+		},
+		{
+			name:     "UTF-16BE with byte order mark",
+			body:     encode(t, unicode.UTF16(unicode.BigEndian, unicode.UseBOM), `<title>日本語のページ</title>`),
+			encoding: "utf-16be",
+			title:    "日本語のページ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, info, err := DecodeHTML(tt.body, tt.contentType)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.encoding, info.Encoding)
+			assert.Equal(t, tt.title, ExtractTitle(mustParse(t, content)))
+		})
+	}
+}
+
+func TestDecodeHTML_Windows1252(t *testing.T) {
+	content, info, err := DecodeHTML(encode(t, charmap.Windows1252, "<h1>Crème brûlée – €5</h1>"), "")
+
+	require.NoError(t, err)
+	assert.Equal(t, EncodingInfo{Encoding: "windows-1252", Source: EncodingSourceHeuristic}, info)
+	assert.Equal(t, "<h1>Crème brûlée – €5</h1>", content)
+}
+
+func TestDecodeHTML_StripsUTF8ByteOrderMark(t *testing.T) {
+	content, _, err := DecodeHTML(append([]byte{0xEF, 0xBB, 0xBF}, "<p>x</p>"...), "")
+
+	require.NoError(t, err)
+	assert.Equal(t, "<p>x</p>", content)
+}
@@ -0,0 +1,228 @@
+package utils
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Doctype variants
+const (
+	DoctypeVariantStrict       = "strict"
+	DoctypeVariantTransitional = "transitional"
+	DoctypeVariantFrameset     = "frameset"
+)
+
+// Rendering modes a browser selects from the DOCTYPE
+const (
+	RenderingModeQuirks        = "quirks"
+	RenderingModeLimitedQuirks = "limited-quirks"
+	RenderingModeNoQuirks      = "no-quirks"
+)
+
+// unknownHTMLVersion is reported when the DOCTYPE is missing or not recognized
+const unknownHTMLVersion = "Unknown HTML version"
+
+// DoctypeInfo describes the document's DOCTYPE and what it implies
+type DoctypeInfo struct {
+	Present       bool   `json:"present"`
+	Name          string `json:"name,omitempty"`
+	Version       string `json:"version"`
+	Variant       string `json:"variant,omitempty"`
+	PublicID      string `json:"public_id,omitempty"`
+	SystemID      string `json:"system_id,omitempty"`
+	RenderingMode string `json:"rendering_mode"`
+}
+
+// doctypeVersion is a known DOCTYPE's HTML version and variant
+type doctypeVersion struct {
+	version string
+	variant string
+}
+
+// publicIDVersions maps lower-cased public identifiers, without the "//en" language suffix, to versions
+var publicIDVersions = map[string]doctypeVersion{
+	"-//ietf//dtd html 2.0":                 {"HTML 2.0", ""},
+	"-//w3c//dtd html 2.0":                  {"HTML 2.0", ""},
+	"-//w3c//dtd html 3.2":                  {"HTML 3.2", ""},
+	"-//w3c//dtd html 3.2 final":            {"HTML 3.2", ""},
+	"-//w3c//dtd html 4.0":                  {"HTML 4.0", DoctypeVariantStrict},
+	"-//w3c//dtd html 4.0 transitional":     {"HTML 4.0", DoctypeVariantTransitional},
+	"-//w3c//dtd html 4.0 frameset":         {"HTML 4.0", DoctypeVariantFrameset},
+	"-//w3c//dtd html 4.01":                 {"HTML 4.01", DoctypeVariantStrict},
+	"-//w3c//dtd html 4.01 transitional":    {"HTML 4.01", DoctypeVariantTransitional},
+	"-//w3c//dtd html 4.01 frameset":        {"HTML 4.01", DoctypeVariantFrameset},
+	"-//w3c//dtd xhtml 1.0 strict":          {"XHTML 1.0", DoctypeVariantStrict},
+	"-//w3c//dtd xhtml 1.0 transitional":    {"XHTML 1.0", DoctypeVariantTransitional},
+	"-//w3c//dtd xhtml 1.0 frameset":        {"XHTML 1.0", DoctypeVariantFrameset},
+	"-//w3c//dtd xhtml 1.1":                 {"XHTML 1.1", ""},
+	"-//w3c//dtd xhtml basic 1.0":           {"XHTML Basic 1.0", ""},
+	"-//w3c//dtd xhtml basic 1.1":           {"XHTML Basic 1.1", ""},
+	"-//w3c//dtd xhtml+rdfa 1.0":            {"XHTML+RDFa 1.0", ""},
+	"-//w3c//dtd xhtml+rdfa 1.1":            {"XHTML+RDFa 1.1", ""},
+	"-//wapforum//dtd xhtml mobile 1.0":     {"XHTML Mobile 1.0", ""},
+	"-//wapforum//dtd xhtml mobile 1.1":     {"XHTML Mobile 1.1", ""},
+	"-//wapforum//dtd xhtml mobile 1.2":     {"XHTML Mobile 1.2", ""},
+	"-//w3c//dtd xhtml 1.1 plus mathml 2.0": {"XHTML 1.1", ""},
+}
+
+// systemIDVersions maps the file name of well-known DTDs to versions, for
+// legacy doctypes that only carry a system identifier
+var systemIDVersions = map[string]doctypeVersion{
+	"strict.dtd":              {"HTML 4.01", DoctypeVariantStrict},
+	"loose.dtd":               {"HTML 4.01", DoctypeVariantTransitional},
+	"frameset.dtd":            {"HTML 4.01", DoctypeVariantFrameset},
+	"xhtml1-strict.dtd":       {"XHTML 1.0", DoctypeVariantStrict},
+	"xhtml1-transitional.dtd": {"XHTML 1.0", DoctypeVariantTransitional},
+	"xhtml1-frameset.dtd":     {"XHTML 1.0", DoctypeVariantFrameset},
+	"xhtml11.dtd":             {"XHTML 1.1", ""},
+	"xhtml-basic10.dtd":       {"XHTML Basic 1.0", ""},
+	"xhtml-basic11.dtd":       {"XHTML Basic 1.1", ""},
+}
+
+// quirkyPublicIDPrefixes are the public identifier prefixes that trigger quirks
+// mode according to the HTML Living Standard
+var quirkyPublicIDPrefixes = []string{
+	"+//silmaril//dtd html pro v0r11 19970101//",
+	"-//as//dtd html 3.0 aswedit + extensions//",
+	"-//advasoft ltd//dtd html 3.0 aswedit + extensions//",
+	"-//ietf//dtd html 2.0 level 1//",
+	"-//ietf//dtd html 2.0 level 2//",
+	"-//ietf//dtd html 2.0 strict level 1//",
+	"-//ietf//dtd html 2.0 strict level 2//",
+	"-//ietf//dtd html 2.0 strict//",
+	"-//ietf//dtd html 2.0//",
+	"-//ietf//dtd html 2.1e//",
+	"-//ietf//dtd html 3.0//",
+	"-//ietf//dtd html 3.2 final//",
+	"-//ietf//dtd html 3.2//",
+	"-//ietf//dtd html 3//",
+	"-//ietf//dtd html level 0//",
+	"-//ietf//dtd html level 1//",
+	"-//ietf//dtd html level 2//",
+	"-//ietf//dtd html level 3//",
+	"-//ietf//dtd html strict level 0//",
+	"-//ietf//dtd html strict level 1//",
+	"-//ietf//dtd html strict level 2//",
+	"-//ietf//dtd html strict level 3//",
+	"-//ietf//dtd html strict//",
+	"-//ietf//dtd html//",
+	"-//metrius//dtd metrius presentational//",
+	"-//microsoft//dtd internet explorer 2.0 html strict//",
+	"-//microsoft//dtd internet explorer 2.0 html//",
+	"-//microsoft//dtd internet explorer 2.0 tables//",
+	"-//microsoft//dtd internet explorer 3.0 html strict//",
+	"-//microsoft//dtd internet explorer 3.0 html//",
+	"-//microsoft//dtd internet explorer 3.0 tables//",
+	"-//netscape comm. corp.//dtd html//",
+	"-//netscape comm. corp.//dtd strict html//",
+	"-//o'reilly and associates//dtd html 2.0//",
+	"-//o'reilly and associates//dtd html extended 1.0//",
+	"-//o'reilly and associates//dtd html extended relaxed 1.0//",
+	"-//sq//dtd html 2.0 hotmetal + extensions//",
+	"-//softquad software//dtd hotmetal pro 6.0::19990601::extensions to html 4.0//",
+	"-//softquad//dtd hotmetal pro 4.0::19971010::extensions to html 4.0//",
+	"-//spyglass//dtd html 2.0 extended//",
+	"-//sun microsystems corp.//dtd hotjava html//",
+	"-//sun microsystems corp.//dtd hotjava strict html//",
+	"-//w3c//dtd html 3 1995-03-24//",
+	"-//w3c//dtd html 3.2 draft//",
+	"-//w3c//dtd html 3.2 final//",
+	"-//w3c//dtd html 3.2//",
+	"-//w3c//dtd html 3.2s draft//",
+	"-//w3c//dtd html 4.0 frameset//",
+	"-//w3c//dtd html 4.0 transitional//",
+	"-//w3c//dtd html experimental 19960712//",
+	"-//w3c//dtd html experimental 970421//",
+	"-//w3c//dtd w3 html//",
+	"-//w3o//dtd w3 html 3.0//",
+	"-//webtechs//dtd mozilla html 2.0//",
+	"-//webtechs//dtd mozilla html//",
+}
+
+// AnalyzeDoctype reports the HTML version, variant, identifiers and rendering mode
+// implied by the document's DOCTYPE node. Only a real DOCTYPE counts; text that looks
+// like one inside comments or scripts is ignored.
+func AnalyzeDoctype(doc *Document) DoctypeInfo {
+	info := doctypeInfo(doc.Doctype())
+	if doc.XML {
+		// Documents parsed as XML never render in quirks mode
+		info.RenderingMode = RenderingModeNoQuirks
+	}
+	return info
+}
+
+// doctypeInfo describes a DOCTYPE node, which may be nil
+func doctypeInfo(node *html.Node) DoctypeInfo {
+	if node == nil {
+		return DoctypeInfo{Version: unknownHTMLVersion, RenderingMode: RenderingModeQuirks}
+	}
+
+	info := DoctypeInfo{Present: true, Name: node.Data, Version: unknownHTMLVersion}
+	publicID, hasPublic := attr(node, "public")
+	systemID, hasSystem := attr(node, "system")
+	info.PublicID = publicID
+	info.SystemID = systemID
+
+	if known, ok := lookupDoctypeVersion(publicID, systemID); ok {
+		info.Version, info.Variant = known.version, known.variant
+	} else if node.Data == "html" && !hasPublic && (!hasSystem || strings.EqualFold(systemID, "about:legacy-compat")) {
+		info.Version = "HTML5"
+	}
+
+	info.RenderingMode = renderingMode(node, publicID, hasPublic, systemID, hasSystem)
+	return info
+}
+
+// lookupDoctypeVersion identifies a version from the public identifier, falling back to the system identifier
+func lookupDoctypeVersion(publicID, systemID string) (doctypeVersion, bool) {
+	if publicID != "" {
+		key := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(publicID)), "//en")
+		known, ok := publicIDVersions[key]
+		return known, ok
+	}
+
+	if systemID != "" {
+		name := strings.ToLower(systemID[strings.LastIndex(systemID, "/")+1:])
+		known, ok := systemIDVersions[name]
+		return known, ok
+	}
+
+	return doctypeVersion{}, false
+}
+
+// renderingMode applies the HTML Living Standard rules for choosing quirks mode from a DOCTYPE
+func renderingMode(node *html.Node, publicID string, hasPublic bool, systemID string, hasSystem bool) string {
+	if node.Data != "html" {
+		return RenderingModeQuirks
+	}
+
+	public := strings.ToLower(publicID)
+	switch public {
+	case "-//w3o//dtd w3 html strict 3.0//en//", "-/w3c/dtd html 4.0 transitional/en", "html":
+		return RenderingModeQuirks
+	}
+	if hasPublic {
+		for _, prefix := range quirkyPublicIDPrefixes {
+			if strings.HasPrefix(public, prefix) {
+				return RenderingModeQuirks
+			}
+		}
+	}
+	if strings.EqualFold(systemID, "http://www.ibm.com/data/dtd/v11/ibmxhtml1-transitional.dtd") {
+		return RenderingModeQuirks
+	}
+
+	html401Loose := strings.HasPrefix(public, "-//w3c//dtd html 4.01 frameset//") ||
+		strings.HasPrefix(public, "-//w3c//dtd html 4.01 transitional//")
+	if html401Loose && !hasSystem {
+		return RenderingModeQuirks
+	}
+	if html401Loose ||
+		strings.HasPrefix(public, "-//w3c//dtd xhtml 1.0 frameset//") ||
+		strings.HasPrefix(public, "-//w3c//dtd xhtml 1.0 transitional//") {
+		return RenderingModeLimitedQuirks
+	}
+
+	return RenderingModeNoQuirks
+}
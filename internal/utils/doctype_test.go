@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeDoctype(t *testing.T) {
+	testCases := []struct {
+		name     string
+		doctype  string
+		expected DoctypeInfo
+	}{
+		{
+			name:     "HTML5",
+			doctype:  `<!DOCTYPE html>`,
+			expected: DoctypeInfo{Present: true, Name: "html", Version: "HTML5", RenderingMode: RenderingModeNoQuirks},
+		},
+		{
+			name:    "HTML5 legacy compat",
+			doctype: `<!DOCTYPE html SYSTEM "about:legacy-compat">`,
+			expected: DoctypeInfo{Present: true, Name: "html", Version: "HTML5", SystemID: "about:legacy-compat",
+				RenderingMode: RenderingModeNoQuirks},
+		},
+		{
+			name:    "HTML 4.01 strict",
+			doctype: `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01//EN" "http://www.w3.org/TR/html4/strict.dtd">`,
+			expected: DoctypeInfo{Present: true, Name: "html", Version: "HTML 4.01", Variant: DoctypeVariantStrict,
+				PublicID: "-//W3C//DTD HTML 4.01//EN", SystemID: "http://www.w3.org/TR/html4/strict.dtd",
+				RenderingMode: RenderingModeNoQuirks},
+		},
+		{
+			name:    "HTML 4.01 transitional with system identifier",
+			doctype: `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Transitional//EN" "http://www.w3.org/TR/html4/loose.dtd">`,
+			expected: DoctypeInfo{Present: true, Name: "html", Version: "HTML 4.01", Variant: DoctypeVariantTransitional,
+				PublicID: "-//W3C//DTD HTML 4.01 Transitional//EN", SystemID: "http://www.w3.org/TR/html4/loose.dtd",
+				RenderingMode: RenderingModeLimitedQuirks},
+		},
+		{
+			name:    "HTML 4.01 frameset without system identifier",
+			doctype: `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Frameset//EN">`,
+			expected: DoctypeInfo{Present: true, Name: "html", Version: "HTML 4.01", Variant: DoctypeVariantFrameset,
+				PublicID: "-//W3C//DTD HTML 4.01 Frameset//EN", RenderingMode: RenderingModeQuirks},
+		},
+		{
+			name:    "XHTML 1.0 transitional",
+			doctype: `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">`,
+			expected: DoctypeInfo{Present: true, Name: "html", Version: "XHTML 1.0", Variant: DoctypeVariantTransitional,
+				PublicID: "-//W3C//DTD XHTML 1.0 Transitional//EN", SystemID: "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd",
+				RenderingMode: RenderingModeLimitedQuirks},
+		},
+		{
+			name:    "XHTML 1.1",
+			doctype: `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.1//EN" "http://www.w3.org/TR/xhtml11/DTD/xhtml11.dtd">`,
+			expected: DoctypeInfo{Present: true, Name: "html", Version: "XHTML 1.1",
+				PublicID: "-//W3C//DTD XHTML 1.1//EN", SystemID: "http://www.w3.org/TR/xhtml11/DTD/xhtml11.dtd",
+				RenderingMode: RenderingModeNoQuirks},
+		},
+		{
+			name:    "XHTML Basic",
+			doctype: `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML Basic 1.1//EN" "http://www.w3.org/TR/xhtml-basic/xhtml-basic11.dtd">`,
+			expected: DoctypeInfo{Present: true, Name: "html", Version: "XHTML Basic 1.1",
+				PublicID: "-//W3C//DTD XHTML Basic 1.1//EN", SystemID: "http://www.w3.org/TR/xhtml-basic/xhtml-basic11.dtd",
+				RenderingMode: RenderingModeNoQuirks},
+		},
+		{
+			name:    "System identifier only",
+			doctype: `<!DOCTYPE html SYSTEM "http://www.w3.org/TR/html4/strict.dtd">`,
+			expected: DoctypeInfo{Present: true, Name: "html", Version: "HTML 4.01", Variant: DoctypeVariantStrict,
+				SystemID: "http://www.w3.org/TR/html4/strict.dtd", RenderingMode: RenderingModeNoQuirks},
+		},
+		{
+			name:    "HTML 3.2",
+			doctype: `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 3.2 Final//EN">`,
+			expected: DoctypeInfo{Present: true, Name: "html", Version: "HTML 3.2",
+				PublicID: "-//W3C//DTD HTML 3.2 Final//EN", RenderingMode: RenderingModeQuirks},
+		},
+		{
+			name:    "Malformed HTML 4.0 Transitional",
+			doctype: `<!DOCTYPE HTML PUBLIC "-/W3C/DTD HTML 4.0 Transitional/EN">`,
+			expected: DoctypeInfo{Present: true, Name: "html", Version: "Unknown HTML version",
+				PublicID: "-/W3C/DTD HTML 4.0 Transitional/EN", RenderingMode: RenderingModeQuirks},
+		},
+		{
+			name:     "Missing",
+			doctype:  ``,
+			expected: DoctypeInfo{Version: "Unknown HTML version", RenderingMode: RenderingModeQuirks},
+		},
+		{
+			name:     "Not html",
+			doctype:  `<!DOCTYPE svg>`,
+			expected: DoctypeInfo{Present: true, Name: "svg", Version: "Unknown HTML version", RenderingMode: RenderingModeQuirks},
+		},
+	}
+
+	for _, testCase := range testCases {
+		info := AnalyzeDoctype(mustParse(t, testCase.doctype+"<html><body></body></html>"))
+		assert.Equal(t, testCase.expected, info, testCase.name)
+	}
+}
+
+func TestAnalyzeDoctype_IgnoresDoctypeInScript(t *testing.T) {
+	htmlContent := `<html><body><script>document.write('<!DOCTYPE html>')</script></body></html>`
+
+	info := AnalyzeDoctype(mustParse(t, htmlContent))
+
+	assert.False(t, info.Present)
+	assert.Equal(t, RenderingModeQuirks, info.RenderingMode)
+}
+
+func TestAnalyzeDoctype_XMLDocumentsAreNeverQuirky(t *testing.T) {
+	withoutDoctype, err := ParseXHTML(`<html xmlns="http://www.w3.org/1999/xhtml"/>`)
+	require.NoError(t, err)
+	transitional, err := ParseXHTML(`<!DOCTYPE html PUBLIC "-//W3C//DTD HTML 4.01 Transitional//EN"><html xmlns="http://www.w3.org/1999/xhtml"/>`)
+	require.NoError(t, err)
+
+	assert.Equal(t, RenderingModeNoQuirks, AnalyzeDoctype(withoutDoctype).RenderingMode)
+	info := AnalyzeDoctype(transitional)
+	assert.Equal(t, DoctypeVariantTransitional, info.Variant)
+	assert.Equal(t, RenderingModeNoQuirks, info.RenderingMode)
+}
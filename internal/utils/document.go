@@ -0,0 +1,185 @@
+package utils
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Document is a parsed HTML page shared by all analyzers, so the page is parsed only once
+type Document struct {
+	Root *html.Node
+
+	// XML is set for documents parsed as XML, i.e. XHTML served as application/xhtml+xml
+	XML bool
+}
+
+// ParseDocument parses HTML content into a Document
+func ParseDocument(htmlContent string) (*Document, error) {
+	root, err := html.Parse(strings.NewReader(htmlContent))
+	if err != nil {
+		return nil, err
+	}
+	return &Document{Root: root}, nil
+}
+
+// Walk visits every node in document order. Returning false from visit skips the node's children.
+func (d *Document) Walk(visit func(n *html.Node) bool) {
+	walkNode(d.Root, visit)
+}
+
+// FindAll returns all elements with one of the given tag names in document order
+func (d *Document) FindAll(tags ...string) []*html.Node {
+	var nodes []*html.Node
+	d.Walk(func(n *html.Node) bool {
+		if n.Type == html.ElementNode {
+			for _, tag := range tags {
+				if n.Data == tag {
+					nodes = append(nodes, n)
+					break
+				}
+			}
+		}
+		return true
+	})
+	return nodes
+}
+
+// Find returns the first element with the given tag name, or nil
+func (d *Document) Find(tag string) *html.Node {
+	var found *html.Node
+	d.Walk(func(n *html.Node) bool {
+		if found == nil && n.Type == html.ElementNode && n.Data == tag {
+			found = n
+		}
+		return found == nil
+	})
+	return found
+}
+
+// Doctype returns the document's DOCTYPE node, or nil if the page has none
+func (d *Document) Doctype() *html.Node {
+	for c := d.Root.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.DoctypeNode {
+			return c
+		}
+	}
+	return nil
+}
+
+// BaseURL returns the URL that relative references on the page resolve against:
+// the first <base href> resolved against the page URL, or the page URL itself
+func (d *Document) BaseURL(page *url.URL) *url.URL {
+	for _, base := range d.FindAll("base") {
+		href, ok := attr(base, "href")
+		if !ok {
+			continue
+		}
+		parsed, err := url.Parse(strings.TrimSpace(href))
+		if err != nil {
+			break
+		}
+		return page.ResolveReference(parsed)
+	}
+	return page
+}
+
+// CSSPath returns a CSS selector path that identifies the element, e.g.
+// "html > body > div#main > form:nth-of-type(2) > input:nth-of-type(1)".
+// The path starts at the nearest ancestor with an id, if any.
+func CSSPath(n *html.Node) string {
+	return cssPath(n, true)
+}
+
+// cssPath builds the CSS path of n, anchoring it at an ancestor's id only when useIDs is set
+func cssPath(n *html.Node, useIDs bool) string {
+	var parts []string
+	for ; n != nil && n.Type == html.ElementNode; n = n.Parent {
+		if id, ok := attr(n, "id"); useIDs && ok && strings.TrimSpace(id) != "" && !strings.ContainsAny(id, " \t\n") {
+			parts = append(parts, n.Data+"#"+id)
+			break
+		}
+
+		part := n.Data
+		if position, total := typePosition(n); total > 1 {
+			part += ":nth-of-type(" + strconv.Itoa(position) + ")"
+		}
+		parts = append(parts, part)
+	}
+
+	slices.Reverse(parts)
+	return strings.Join(parts, " > ")
+}
+
+// typePosition returns the 1-based position of n among its siblings of the same tag and how many there are
+func typePosition(n *html.Node) (position, total int) {
+	if n.Parent == nil {
+		return 1, 1
+	}
+	for sibling := n.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+		if sibling.Type == html.ElementNode && sibling.Data == n.Data {
+			total++
+			if sibling == n {
+				position = total
+			}
+		}
+	}
+	return position, total
+}
+
+// walkNode visits n and its descendants in document order. Returning false from visit skips the node's children.
+func walkNode(n *html.Node, visit func(n *html.Node) bool) {
+	if !visit(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walkNode(c, visit)
+	}
+}
+
+// attr returns the value of the named attribute. The parser lower-cases attribute names.
+func attr(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// nodeText returns the whitespace-normalized text content of a node and its descendants
+func nodeText(n *html.Node) string {
+	var sb strings.Builder
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+			sb.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(n)
+
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// textAlternative returns the text of n, including the alt text of images inside it
+func textAlternative(n *html.Node) string {
+	var parts []string
+	walkNode(n, func(c *html.Node) bool {
+		switch {
+		case c.Type == html.TextNode:
+			parts = append(parts, c.Data)
+		case c.Type == html.ElementNode && c.Data == "img":
+			alt, _ := attr(c, "alt")
+			parts = append(parts, alt)
+		}
+		return true
+	})
+	return strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+}
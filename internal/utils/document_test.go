@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+// mustParse parses HTML content for tests, failing the test on error
+func mustParse(t *testing.T, htmlContent string) *Document {
+	t.Helper()
+	doc, err := ParseDocument(htmlContent)
+	require.NoError(t, err)
+	return doc
+}
+
+func TestDocument_FindAll(t *testing.T) {
+	doc := mustParse(t, `<body><p>One</p><div><p>Two</p><span>x</span></div></body>`)
+
+	paragraphs := doc.FindAll("p")
+	assert.Len(t, paragraphs, 2)
+	assert.Equal(t, "Two", nodeText(paragraphs[1]))
+
+	assert.Len(t, doc.FindAll("p", "span"), 3)
+}
+
+func TestDocument_Find(t *testing.T) {
+	doc := mustParse(t, `<body><p id="first">One</p><p>Two</p></body>`)
+
+	first := doc.Find("p")
+	id, ok := attr(first, "id")
+	assert.True(t, ok)
+	assert.Equal(t, "first", id)
+
+	assert.Nil(t, doc.Find("table"))
+}
+
+func TestDocument_Doctype(t *testing.T) {
+	assert.NotNil(t, mustParse(t, `<!DOCTYPE html><html></html>`).Doctype())
+	assert.Nil(t, mustParse(t, `<html></html>`).Doctype())
+}
+
+func TestDocument_BaseURL(t *testing.T) {
+	page, err := url.Parse("https://example.com/blog/post")
+	require.NoError(t, err)
+
+	assert.Equal(t, page, mustParse(t, `<html></html>`).BaseURL(page))
+	assert.Equal(t, "https://cdn.example.com/assets/", mustParse(t, `<head><base target="_blank"><base href="//cdn.example.com/assets/"><base href="/ignored/"></head>`).BaseURL(page).String())
+	assert.Equal(t, "https://example.com/docs/", mustParse(t, `<head><base href="/docs/"></head>`).BaseURL(page).String())
+}
+
+func TestDocument_WalkSkipsChildren(t *testing.T) {
+	doc := mustParse(t, `<body><div><p>Hidden</p></div><p>Shown</p></body>`)
+
+	var seen []string
+	doc.Walk(func(n *html.Node) bool {
+		if n.Type == html.ElementNode && n.Data == "p" {
+			seen = append(seen, nodeText(n))
+		}
+		return !(n.Type == html.ElementNode && n.Data == "div")
+	})
+
+	assert.Equal(t, []string{"Shown"}, seen)
+}
+
+func TestNodeText_NormalizesWhitespace(t *testing.T) {
+	doc := mustParse(t, `<p>  Hello
+		<b>brave</b>   new world </p>`)
+
+	assert.Equal(t, "Hello brave new world", nodeText(doc.Find("p")))
+}
+
+func TestCSSPath(t *testing.T) {
+	doc := mustParse(t, `<html><body>
+		<div><p>First</p></div>
+		<div id="main"><form></form><form><input name="a"><input name="b"></form></div>
+	</body></html>`)
+
+	inputs := doc.FindAll("input")
+	paragraph := doc.Find("p")
+
+	assert.Equal(t, "div#main > form:nth-of-type(2) > input:nth-of-type(2)", CSSPath(inputs[1]))
+	assert.Equal(t, "html > body > div:nth-of-type(1) > p", CSSPath(paragraph))
+}
@@ -0,0 +1,20 @@
+package utils
+
+// Finding severities, from least to most severe
+const (
+	SeverityInfo   = "info"
+	SeverityLow    = "low"
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+)
+
+// Finding is a single issue reported by an analyzer
+type Finding struct {
+	// Rule is a stable identifier for the check that produced the finding
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+
+	// Path is the CSS path of the offending element, if there is one
+	Path string `json:"path,omitempty"`
+}
@@ -0,0 +1,311 @@
+package utils
+
+import (
+	"math"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Form types reported by ClassifyForms
+const (
+	FormTypeLogin         = "login"
+	FormTypeSignup        = "signup"
+	FormTypePasswordReset = "password_reset"
+	FormTypeSearch        = "search"
+	FormTypeNewsletter    = "newsletter"
+	FormTypeOther         = "other"
+)
+
+// minFormScore is the score a form type needs before it is preferred over "other"
+const minFormScore = 0.3
+
+// FormClassification is the most likely purpose of a single form
+type FormClassification struct {
+	// Index is the position of the form among the page's forms. Password fields
+	// outside any form are grouped into a standalone entry with Index -1.
+	Index      int      `json:"index"`
+	ID         string   `json:"id,omitempty"`
+	Action     string   `json:"action,omitempty"`
+	Type       string   `json:"type"`
+	Confidence float64  `json:"confidence"`
+	Signals    []string `json:"signals,omitempty"`
+}
+
+// Keywords matched against submit labels, action URLs and the form's own id, name and class
+var formKeywords = map[string][]string{
+	FormTypeLogin:         {"log in", "login", "log-in", "sign in", "signin", "sign-in", "session", "auth"},
+	FormTypeSignup:        {"sign up", "signup", "sign-up", "register", "registration", "create account", "create an account", "join"},
+	FormTypePasswordReset: {"reset", "forgot", "recover", "change password", "new password", "lost password"},
+	FormTypeSearch:        {"search", "find"},
+	FormTypeNewsletter:    {"subscribe", "newsletter", "mailing list"},
+}
+
+// formFeatures are the structural facts a classification is based on
+type formFeatures struct {
+	passwords       int
+	currentPassword bool
+	newPassword     bool
+	usernameField   bool
+	emailFields     int
+	textFields      int
+	searchField     bool
+	searchRole      bool
+	oneTimeCode     bool
+	labels          string
+	action          string
+	identity        string
+}
+
+// ClassifyForms classifies every form on the page as login, signup, password reset,
+// search, newsletter or other, using its fields, autocomplete hints, submit labels
+// and action URL
+func ClassifyForms(doc *Document) []FormClassification {
+	var classifications []FormClassification
+
+	index := 0
+	var orphans []*html.Node
+	walkNode(doc.Root, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		switch n.Data {
+		case "form":
+			classification := classifyFeatures(extractFormFeatures(n, []*html.Node{n}))
+			classification.Index = index
+			classification.ID, _ = attr(n, "id")
+			classification.Action, _ = attr(n, "action")
+			classifications = append(classifications, classification)
+			index++
+			return false
+		case "input", "button":
+			orphans = append(orphans, n)
+		}
+		return true
+	})
+
+	// Single-page apps often render login fields without a form element
+	if features := extractFormFeatures(nil, orphans); features.passwords > 0 {
+		classification := classifyFeatures(features)
+		classification.Index = -1
+		classifications = append(classifications, classification)
+	}
+
+	return classifications
+}
+
+// extractFormFeatures collects the features of a form element, or of loose controls when form is nil
+func extractFormFeatures(form *html.Node, roots []*html.Node) formFeatures {
+	var features formFeatures
+	var labels []string
+
+	if form != nil {
+		action, _ := attr(form, "action")
+		features.action = strings.ToLower(action)
+		id, _ := attr(form, "id")
+		name, _ := attr(form, "name")
+		class, _ := attr(form, "class")
+		features.identity = strings.ToLower(strings.Join([]string{id, name, class}, " "))
+		if role, _ := attr(form, "role"); strings.EqualFold(role, "search") {
+			features.searchRole = true
+		}
+	}
+
+	for _, root := range roots {
+		walkNode(root, func(n *html.Node) bool {
+			if n.Type != html.ElementNode {
+				return true
+			}
+
+			switch n.Data {
+			case "input":
+				inputType, _ := attr(n, "type")
+				inputType = strings.ToLower(strings.TrimSpace(inputType))
+				if inputType == "submit" || inputType == "image" || inputType == "button" {
+					value, _ := attr(n, "value")
+					alt, _ := attr(n, "alt")
+					labels = append(labels, value, alt)
+					return true
+				}
+				features.addInput(n, inputType)
+			case "button":
+				buttonType, _ := attr(n, "type")
+				if buttonType == "" || strings.EqualFold(buttonType, "submit") {
+					ariaLabel, _ := attr(n, "aria-label")
+					labels = append(labels, nodeText(n), ariaLabel)
+				}
+			}
+			return true
+		})
+	}
+
+	features.labels = strings.ToLower(strings.Join(labels, " "))
+	return features
+}
+
+// addInput records what a single input field says about the form
+func (f *formFeatures) addInput(n *html.Node, inputType string) {
+	autocomplete, _ := attr(n, "autocomplete")
+	autocomplete = strings.ToLower(autocomplete)
+	name, _ := attr(n, "name")
+	id, _ := attr(n, "id")
+	placeholder, _ := attr(n, "placeholder")
+	hints := strings.ToLower(strings.Join([]string{name, id, placeholder, autocomplete}, " "))
+
+	switch inputType {
+	case "password":
+		f.passwords++
+		if strings.Contains(autocomplete, "current-password") {
+			f.currentPassword = true
+		}
+		if strings.Contains(autocomplete, "new-password") {
+			f.newPassword = true
+		}
+	case "email":
+		f.emailFields++
+		f.textFields++
+	case "search":
+		f.searchField = true
+	case "", "text", "tel":
+		f.textFields++
+		switch {
+		case strings.Contains(hints, "email") || strings.Contains(hints, "e-mail"):
+			f.emailFields++
+		case strings.Contains(hints, "user") || strings.Contains(hints, "login"):
+			f.usernameField = true
+		case name == "q" || name == "s" || strings.Contains(hints, "query") || strings.Contains(hints, "search"):
+			f.searchField = true
+		}
+	}
+
+	if strings.Contains(autocomplete, "username") {
+		f.usernameField = true
+	}
+	if strings.Contains(autocomplete, "one-time-code") {
+		f.oneTimeCode = true
+	}
+}
+
+// formScore accumulates evidence for one form type
+type formScore struct {
+	score   float64
+	signals []string
+}
+
+// add records a piece of evidence with its weight
+func (s *formScore) add(weight float64, signal string) {
+	s.score += weight
+	s.signals = append(s.signals, signal)
+}
+
+// classifyFeatures scores every form type and picks the best one
+func classifyFeatures(f formFeatures) FormClassification {
+	scores := map[string]*formScore{}
+	for _, formType := range []string{FormTypeLogin, FormTypeSignup, FormTypePasswordReset, FormTypeSearch, FormTypeNewsletter} {
+		scores[formType] = &formScore{}
+	}
+
+	login, signup, reset := scores[FormTypeLogin], scores[FormTypeSignup], scores[FormTypePasswordReset]
+	search, newsletter := scores[FormTypeSearch], scores[FormTypeNewsletter]
+
+	switch {
+	case f.passwords == 1 && !f.names(FormTypeSignup) && !f.names(FormTypePasswordReset):
+		// A lone password field only suggests a login when the labels and action do not
+		// say otherwise; many sign-up forms skip the confirmation field
+		login.add(0.4, "single_password_field")
+	case f.passwords >= 2:
+		signup.add(0.3, "multiple_password_fields")
+		reset.add(0.2, "multiple_password_fields")
+	}
+	if f.currentPassword && !f.newPassword {
+		login.add(0.3, "autocomplete:current-password")
+	}
+	if f.newPassword {
+		signup.add(0.2, "autocomplete:new-password")
+		reset.add(0.2, "autocomplete:new-password")
+	}
+	if f.currentPassword && f.newPassword {
+		reset.add(0.3, "current_and_new_password")
+	}
+	if f.passwords > 0 && (f.usernameField || f.emailFields > 0) {
+		login.add(0.2, "username_field")
+		signup.add(0.1, "username_field")
+	}
+	if f.passwords > 0 && f.textFields >= 3 {
+		signup.add(0.2, "many_text_fields")
+	}
+	if f.oneTimeCode {
+		login.add(0.1, "autocomplete:one-time-code")
+	}
+
+	if f.passwords == 0 {
+		if f.searchRole {
+			search.add(0.5, "role:search")
+		}
+		if f.searchField {
+			search.add(0.4, "search_field")
+		}
+		if f.emailFields == 1 && f.textFields <= 2 {
+			newsletter.add(0.3, "single_email_field")
+			reset.add(0.1, "single_email_field")
+		}
+	}
+
+	for formType, keywords := range formKeywords {
+		score := scores[formType]
+		// Password forms are never searches or subscriptions, whatever their labels say
+		if f.passwords > 0 && (formType == FormTypeSearch || formType == FormTypeNewsletter) {
+			continue
+		}
+		if containsAny(f.labels, keywords) {
+			score.add(0.3, "submit_label:"+formType)
+		}
+		if containsAny(f.action, keywords) {
+			score.add(0.2, "action:"+formType)
+		}
+		if containsAny(f.identity, keywords) {
+			score.add(0.1, "attributes:"+formType)
+		}
+	}
+
+	best, bestScore, tied := FormTypeOther, 0.0, false
+	for _, formType := range []string{FormTypeLogin, FormTypeSignup, FormTypePasswordReset, FormTypeSearch, FormTypeNewsletter} {
+		score := scores[formType].score
+		switch {
+		case roundConfidence(score) == roundConfidence(bestScore):
+			tied = true
+		case score > bestScore:
+			best, bestScore, tied = formType, score, false
+		}
+	}
+
+	// Equal evidence for two types is ambiguous, so neither is preferred
+	if bestScore < minFormScore || tied {
+		return FormClassification{Type: FormTypeOther, Confidence: roundConfidence(1 - bestScore)}
+	}
+	return FormClassification{
+		Type:       best,
+		Confidence: roundConfidence(math.Min(bestScore, 1)),
+		Signals:    scores[best].signals,
+	}
+}
+
+// names reports whether the submit labels or the action URL use a keyword of formType
+func (f formFeatures) names(formType string) bool {
+	return containsAny(f.labels, formKeywords[formType]) || containsAny(f.action, formKeywords[formType])
+}
+
+// containsAny reports whether s contains any of the keywords
+func containsAny(s string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(s, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// roundConfidence rounds a confidence score to two decimals
+func roundConfidence(confidence float64) float64 {
+	return math.Round(confidence*100) / 100
+}
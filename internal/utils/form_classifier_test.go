@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyForms(t *testing.T) {
+	testCases := []struct {
+		name     string
+		form     string
+		expected string
+	}{
+		{
+			name: "Login",
+			form: `<form action="/session" method="post">
+				<input type="email" name="email" autocomplete="username">
+				<input type="password" name="password" autocomplete="current-password">
+				<button>Sign in</button>
+			</form>`,
+			expected: FormTypeLogin,
+		},
+		{
+			name:     "Login with unquoted upper-case attributes",
+			form:     `<FORM><INPUT TYPE=TEXT NAME=user><INPUT TYPE=PASSWORD NAME=pass><INPUT TYPE=SUBMIT VALUE="Log in"></FORM>`,
+			expected: FormTypeLogin,
+		},
+		{
+			name: "Signup",
+			form: `<form action="/users/register">
+				<input type="text" name="full_name">
+				<input type="email" name="email">
+				<input type="password" name="password" autocomplete="new-password">
+				<input type="password" name="password_confirmation" autocomplete="new-password">
+				<button type="submit">Create account</button>
+			</form>`,
+			expected: FormTypeSignup,
+		},
+		{
+			name: "Signup with a single password field",
+			form: `<form action="/register">
+				<input name=name>
+				<input type=email>
+				<input type=password>
+				<button>Create account</button>
+			</form>`,
+			expected: FormTypeSignup,
+		},
+		{
+			name: "Password change",
+			form: `<form action="/account/password">
+				<input type="password" autocomplete="current-password">
+				<input type="password" autocomplete="new-password">
+				<input type="password" autocomplete="new-password">
+				<button>Change password</button>
+			</form>`,
+			expected: FormTypePasswordReset,
+		},
+		{
+			name: "Forgot password",
+			form: `<form action="/password/forgot">
+				<input type="email" name="email">
+				<button>Send reset link</button>
+			</form>`,
+			expected: FormTypePasswordReset,
+		},
+		{
+			name:     "Search",
+			form:     `<form role="search" action="/search"><input type="search" name="q"><button>Go</button></form>`,
+			expected: FormTypeSearch,
+		},
+		{
+			name: "Newsletter",
+			form: `<form action="https://lists.example.com/subscribe">
+				<input type="email" name="email" placeholder="you@example.com">
+				<button>Subscribe</button>
+			</form>`,
+			expected: FormTypeNewsletter,
+		},
+		{
+			name: "Contact",
+			form: `<form action="/contact">
+				<input type="text" name="name"><textarea name="message"></textarea>
+				<button>Send</button>
+			</form>`,
+			expected: FormTypeOther,
+		},
+	}
+
+	for _, testCase := range testCases {
+		forms := ClassifyForms(mustParse(t, testCase.form))
+
+		if assert.Len(t, forms, 1, testCase.name) {
+			assert.Equal(t, testCase.expected, forms[0].Type, "%s: %+v", testCase.name, forms[0])
+			assert.Greater(t, forms[0].Confidence, 0.0, testCase.name)
+			assert.LessOrEqual(t, forms[0].Confidence, 1.0, testCase.name)
+		}
+	}
+}
+
+func TestClassifyForms_ReportsPositionAndSignals(t *testing.T) {
+	page := `<form id="search"><input type="search" name="q"></form>
+		<form id="login" action="/login"><input name="username"><input type="password"></form>`
+
+	forms := ClassifyForms(mustParse(t, page))
+
+	assert.Len(t, forms, 2)
+	assert.Equal(t, 1, forms[1].Index)
+	assert.Equal(t, "login", forms[1].ID)
+	assert.Equal(t, "/login", forms[1].Action)
+	assert.Equal(t, FormTypeLogin, forms[1].Type)
+	assert.Contains(t, forms[1].Signals, "single_password_field")
+	assert.Contains(t, forms[1].Signals, "action:login")
+}
+
+func TestClassifyForms_StandalonePasswordField(t *testing.T) {
+	page := `<div id="app"><input name="user"><input type="password"><button>Log in</button></div>`
+
+	forms := ClassifyForms(mustParse(t, page))
+
+	assert.Len(t, forms, 1)
+	assert.Equal(t, -1, forms[0].Index)
+	assert.Equal(t, FormTypeLogin, forms[0].Type)
+}
+
+func TestClassifyForms_TieIsOther(t *testing.T) {
+	// The label says newsletter as strongly as the action and class say sign-up
+	forms := ClassifyForms(mustParse(t, `<form action="/register" class="signup"><input name="first_name"><button>Subscribe</button></form>`))
+
+	assert.Len(t, forms, 1)
+	assert.Equal(t, FormTypeOther, forms[0].Type)
+}
+
+func TestClassifyForms_StrongerEvidenceRaisesConfidence(t *testing.T) {
+	weak := ClassifyForms(mustParse(t, `<form><input type="password"></form>`))
+	strong := ClassifyForms(mustParse(t, `<form action="/login">
+		<input autocomplete="username"><input type="password" autocomplete="current-password"><button>Log in</button>
+	</form>`))
+
+	assert.Equal(t, FormTypeLogin, weak[0].Type)
+	assert.Equal(t, FormTypeLogin, strong[0].Type)
+	assert.Greater(t, strong[0].Confidence, weak[0].Confidence)
+}
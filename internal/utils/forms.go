@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Rules reported for forms
+const (
+	RuleFormInsecureAction       = "form-insecure-action"
+	RuleFormHTTPSToHTTP          = "form-https-to-http"
+	RuleFormPasswordAutocomplete = "form-password-autocomplete"
+	RuleFormMissingCSRFToken     = "form-missing-csrf-token"
+)
+
+// csrfFieldPattern matches the names hidden anti-CSRF fields commonly use
+var csrfFieldPattern = regexp.MustCompile(`(?i)csrf|xsrf|authenticity|requestverificationtoken|nonce|token`)
+
+// FormInput is a single named control of a form
+type FormInput struct {
+	Name         string `json:"name,omitempty"`
+	Type         string `json:"type"`
+	Autocomplete string `json:"autocomplete,omitempty"`
+	Required     bool   `json:"required,omitempty"`
+}
+
+// FormInfo describes a form and what it submits where
+type FormInfo struct {
+	Index  int    `json:"index"`
+	ID     string `json:"id,omitempty"`
+	Method string `json:"method"`
+	Action string `json:"action"`
+
+	// ResolvedAction is the absolute URL the form submits to
+	ResolvedAction string      `json:"resolved_action"`
+	CrossOrigin    bool        `json:"cross_origin"`
+	Inputs         []FormInput `json:"inputs"`
+	Findings       []Finding   `json:"findings,omitempty"`
+}
+
+// InventoryForms lists every form on the page with its method, resolved action
+// and inputs, and reports transport and hardening issues
+func InventoryForms(doc *Document, pageURL string) []FormInfo {
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		page = &url.URL{}
+	}
+	base := doc.BaseURL(page)
+
+	forms := []FormInfo{}
+	for index, form := range doc.FindAll("form") {
+		forms = append(forms, inventoryForm(form, index, page, base))
+	}
+	return forms
+}
+
+// inventoryForm describes a single form element. Actions resolve against base,
+// while origin checks compare with the page itself.
+func inventoryForm(form *html.Node, index int, page, base *url.URL) FormInfo {
+	info := FormInfo{Index: index, Method: http.MethodGet, Inputs: []FormInput{}}
+	info.ID, _ = attr(form, "id")
+	if method, _ := attr(form, "method"); strings.EqualFold(strings.TrimSpace(method), "post") {
+		info.Method = http.MethodPost
+	}
+	info.Action, _ = attr(form, "action")
+
+	// An empty action submits to the page itself
+	action := page
+	if trimmed := strings.TrimSpace(info.Action); trimmed != "" {
+		if parsed, err := url.Parse(trimmed); err == nil {
+			action = base.ResolveReference(parsed)
+		}
+	}
+	info.ResolvedAction = action.String()
+	info.CrossOrigin = !sameOrigin(page, action)
+
+	formPath := CSSPath(form)
+	hasPassword, hasCSRFToken := false, false
+
+	walkNode(form, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+
+		var input FormInput
+		switch n.Data {
+		case "input":
+			inputType, _ := attr(n, "type")
+			input.Type = strings.ToLower(strings.TrimSpace(inputType))
+			if input.Type == "" {
+				input.Type = "text"
+			}
+		case "select", "textarea":
+			input.Type = n.Data
+		default:
+			return true
+		}
+
+		input.Name, _ = attr(n, "name")
+		input.Autocomplete, _ = attr(n, "autocomplete")
+		_, input.Required = attr(n, "required")
+		info.Inputs = append(info.Inputs, input)
+
+		switch {
+		case input.Type == "password":
+			hasPassword = true
+			if strings.TrimSpace(input.Autocomplete) == "" {
+				info.Findings = append(info.Findings, Finding{
+					Rule:     RuleFormPasswordAutocomplete,
+					Severity: SeverityLow,
+					Message:  "Password field has no autocomplete hint (current-password or new-password)",
+					Path:     CSSPath(n),
+				})
+			}
+		case input.Type == "hidden" && csrfFieldPattern.MatchString(input.Name):
+			hasCSRFToken = true
+		}
+		return true
+	})
+
+	if action.Scheme == "http" {
+		severity := SeverityMedium
+		if hasPassword {
+			severity = SeverityHigh
+		}
+		if page.Scheme == "https" {
+			info.Findings = append(info.Findings, Finding{
+				Rule:     RuleFormHTTPSToHTTP,
+				Severity: SeverityHigh,
+				Message:  fmt.Sprintf("Form on an HTTPS page submits to an HTTP action (%s)", info.ResolvedAction),
+				Path:     formPath,
+			})
+		} else {
+			info.Findings = append(info.Findings, Finding{
+				Rule:     RuleFormInsecureAction,
+				Severity: severity,
+				Message:  "Form submits over plain HTTP",
+				Path:     formPath,
+			})
+		}
+	}
+
+	if info.Method == http.MethodPost && !hasCSRFToken {
+		info.Findings = append(info.Findings, Finding{
+			Rule:     RuleFormMissingCSRFToken,
+			Severity: SeverityMedium,
+			Message:  "POST form has no hidden field that looks like a CSRF token",
+			Path:     formPath,
+		})
+	}
+
+	return info
+}
+
+// sameOrigin reports whether two URLs share scheme, host and port
+func sameOrigin(a, b *url.URL) bool {
+	return strings.EqualFold(a.Scheme, b.Scheme) &&
+		strings.EqualFold(a.Hostname(), b.Hostname()) &&
+		effectivePort(a) == effectivePort(b)
+}
+
+// effectivePort returns the URL's port, defaulting by scheme
+func effectivePort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http":
+		return "80"
+	case "https":
+		return "443"
+	}
+	return ""
+}
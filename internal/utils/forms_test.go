@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// findingRules returns the rules of the given findings in order
+func findingRules(findings []Finding) []string {
+	var rules []string
+	for _, finding := range findings {
+		rules = append(rules, finding.Rule)
+	}
+	return rules
+}
+
+func TestInventoryForms(t *testing.T) {
+	page := `<body>
+		<form id="login" method="post" action="/session">
+			<input type="hidden" name="authenticity_token" value="x">
+			<input type="email" name="email" autocomplete="username" required>
+			<input type="password" name="password" autocomplete="current-password">
+			<select name="region"></select>
+			<textarea name="note"></textarea>
+			<button>Sign in</button>
+		</form>
+		<form action="https://search.example.net/q"><input name="q"></form>
+	</body>`
+
+	forms := InventoryForms(mustParse(t, page), "https://example.com/account/")
+
+	assert.Len(t, forms, 2)
+
+	assert.Equal(t, FormInfo{
+		Index:          0,
+		ID:             "login",
+		Method:         "POST",
+		Action:         "/session",
+		ResolvedAction: "https://example.com/session",
+		Inputs: []FormInput{
+			{Name: "authenticity_token", Type: "hidden"},
+			{Name: "email", Type: "email", Autocomplete: "username", Required: true},
+			{Name: "password", Type: "password", Autocomplete: "current-password"},
+			{Name: "region", Type: "select"},
+			{Name: "note", Type: "textarea"},
+		},
+	}, forms[0])
+
+	assert.Equal(t, "GET", forms[1].Method)
+	assert.Equal(t, "https://search.example.net/q", forms[1].ResolvedAction)
+	assert.True(t, forms[1].CrossOrigin)
+	assert.Equal(t, []FormInput{{Name: "q", Type: "text"}}, forms[1].Inputs)
+	assert.Empty(t, forms[1].Findings)
+}
+
+func TestInventoryForms_EmptyActionSubmitsToPage(t *testing.T) {
+	forms := InventoryForms(mustParse(t, `<form><input name="q"></form>`), "https://example.com/search?x=1")
+
+	assert.Equal(t, "https://example.com/search?x=1", forms[0].ResolvedAction)
+	assert.False(t, forms[0].CrossOrigin)
+}
+
+func TestInventoryForms_ResolvesAgainstBaseHref(t *testing.T) {
+	page := `<head><base href="https://accounts.example.net/app/"></head>
+		<form action="login"><input name="user"></form>
+		<form><input name="q"></form>`
+
+	forms := InventoryForms(mustParse(t, page), "https://example.com/")
+
+	assert.Equal(t, "https://accounts.example.net/app/login", forms[0].ResolvedAction)
+	assert.True(t, forms[0].CrossOrigin, "Origin is compared with the page, not the base URL")
+	assert.Equal(t, "https://example.com/", forms[1].ResolvedAction, "An empty action submits to the page itself")
+}
+
+func TestInventoryForms_Findings(t *testing.T) {
+	page := `<form method="post" action="http://example.com/login">
+		<input name="user"><input type="password" name="pass">
+	</form>`
+
+	secure := InventoryForms(mustParse(t, page), "https://example.com/")
+	assert.Equal(t, []string{RuleFormPasswordAutocomplete, RuleFormHTTPSToHTTP, RuleFormMissingCSRFToken}, findingRules(secure[0].Findings))
+	assert.Equal(t, "html > body > form > input:nth-of-type(2)", secure[0].Findings[0].Path)
+	assert.Equal(t, SeverityHigh, secure[0].Findings[1].Severity)
+
+	plain := InventoryForms(mustParse(t, page), "http://example.com/")
+	assert.Equal(t, []string{RuleFormPasswordAutocomplete, RuleFormInsecureAction, RuleFormMissingCSRFToken}, findingRules(plain[0].Findings))
+	assert.Equal(t, SeverityHigh, plain[0].Findings[1].Severity, "Plain HTTP password forms are high severity")
+}
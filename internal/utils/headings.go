@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Rules reported for the heading outline
+const (
+	RuleHeadingMultipleH1   = "heading-multiple-h1"
+	RuleHeadingSkippedLevel = "heading-skipped-level"
+	RuleHeadingEmpty        = "heading-empty"
+	RuleHeadingAriaHidden   = "heading-aria-hidden"
+)
+
+// Heading is a single entry of the page outline
+type Heading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+
+	// Depth is the nesting depth in the outline; top-level headings have depth 0
+	Depth int `json:"depth"`
+
+	// Hidden is set when the heading is hidden from assistive technology
+	Hidden bool   `json:"hidden,omitempty"`
+	Path   string `json:"path"`
+}
+
+// HeadingOutline is the ordered list of h1–h6 headings and the issues found in it
+type HeadingOutline struct {
+	Headings []Heading `json:"headings"`
+	Findings []Finding `json:"findings,omitempty"`
+}
+
+// headingLevel returns the level of an h1–h6 element, or 0 for any other node
+func headingLevel(n *html.Node) int {
+	if n.Type != html.ElementNode || len(n.Data) != 2 || n.Data[0] != 'h' {
+		return 0
+	}
+	if level := int(n.Data[1] - '0'); level >= 1 && level <= 6 {
+		return level
+	}
+	return 0
+}
+
+// BuildHeadingOutline builds the page outline in document order and checks its hierarchy:
+// multiple h1s, skipped levels, empty headings and headings hidden with aria-hidden
+func BuildHeadingOutline(doc *Document) HeadingOutline {
+	outline := HeadingOutline{Headings: []Heading{}}
+
+	var open []int
+	previous, h1s := 0, 0
+	doc.Walk(func(n *html.Node) bool {
+		level := headingLevel(n)
+		if level == 0 {
+			return true
+		}
+
+		for len(open) > 0 && open[len(open)-1] >= level {
+			open = open[:len(open)-1]
+		}
+		heading := Heading{
+			Level:  level,
+			Text:   textAlternative(n),
+			Depth:  len(open),
+			Hidden: ariaHidden(n),
+			Path:   CSSPath(n),
+		}
+		open = append(open, level)
+		outline.Headings = append(outline.Headings, heading)
+
+		if level == 1 {
+			h1s++
+			if h1s == 2 {
+				outline.Findings = append(outline.Findings, Finding{
+					Rule:     RuleHeadingMultipleH1,
+					Severity: SeverityLow,
+					Message:  "Page has more than one h1",
+					Path:     heading.Path,
+				})
+			}
+		}
+		if previous > 0 && level > previous+1 {
+			outline.Findings = append(outline.Findings, Finding{
+				Rule:     RuleHeadingSkippedLevel,
+				Severity: SeverityMedium,
+				Message:  fmt.Sprintf("Heading level skipped from h%d to h%d", previous, level),
+				Path:     heading.Path,
+			})
+		}
+		if heading.Text == "" {
+			outline.Findings = append(outline.Findings, Finding{
+				Rule:     RuleHeadingEmpty,
+				Severity: SeverityMedium,
+				Message:  fmt.Sprintf("h%d has no text", level),
+				Path:     heading.Path,
+			})
+		}
+		if heading.Hidden {
+			outline.Findings = append(outline.Findings, Finding{
+				Rule:     RuleHeadingAriaHidden,
+				Severity: SeverityMedium,
+				Message:  fmt.Sprintf("h%d is hidden from assistive technology with aria-hidden", level),
+				Path:     heading.Path,
+			})
+		}
+
+		previous = level
+		return false
+	})
+
+	return outline
+}
+
+// ariaHidden reports whether n or one of its ancestors has aria-hidden="true"
+func ariaHidden(n *html.Node) bool {
+	for ; n != nil; n = n.Parent {
+		if n.Type == html.ElementNode {
+			if hidden, _ := attr(n, "aria-hidden"); strings.EqualFold(strings.TrimSpace(hidden), "true") {
+				return true
+			}
+		}
+	}
+	return false
+}
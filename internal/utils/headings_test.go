@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildHeadingOutline(t *testing.T) {
+	page := `<body>
+		<h1>Guide</h1>
+		<h2>Install</h2>
+		<h3>Linux</h3>
+		<h2>Usage <em>basics</em></h2>
+		<h1><img src="logo.png" alt="Company"></h1>
+	</body>`
+
+	outline := BuildHeadingOutline(mustParse(t, page))
+
+	var entries []Heading
+	for _, heading := range outline.Headings {
+		heading.Path = ""
+		entries = append(entries, heading)
+	}
+	assert.Equal(t, []Heading{
+		{Level: 1, Text: "Guide", Depth: 0},
+		{Level: 2, Text: "Install", Depth: 1},
+		{Level: 3, Text: "Linux", Depth: 2},
+		{Level: 2, Text: "Usage basics", Depth: 1},
+		{Level: 1, Text: "Company", Depth: 0},
+	}, entries)
+	assert.Equal(t, "html > body > h2:nth-of-type(2)", outline.Headings[3].Path)
+	assert.Equal(t, []string{RuleHeadingMultipleH1}, findingRules(outline.Findings))
+}
+
+func TestBuildHeadingOutline_Findings(t *testing.T) {
+	page := `<body>
+		<h2>Intro</h2>
+		<h4>Details</h4>
+		<h3>   </h3>
+		<div aria-hidden="true"><h3>Decoration</h3></div>
+	</body>`
+
+	outline := BuildHeadingOutline(mustParse(t, page))
+
+	assert.Equal(t, []string{RuleHeadingSkippedLevel, RuleHeadingEmpty, RuleHeadingAriaHidden}, findingRules(outline.Findings))
+	assert.Equal(t, "Heading level skipped from h2 to h4", outline.Findings[0].Message)
+	assert.True(t, outline.Headings[3].Hidden)
+	assert.Equal(t, 1, outline.Headings[1].Depth, "A skipped level still nests under the previous heading")
+}
+
+func TestBuildHeadingOutline_NoHeadings(t *testing.T) {
+	outline := BuildHeadingOutline(mustParse(t, `<p>Text</p><hr>`))
+
+	assert.Empty(t, outline.Headings)
+	assert.NotNil(t, outline.Headings, "An empty outline is serialized as an empty list")
+	assert.Empty(t, outline.Findings)
+}
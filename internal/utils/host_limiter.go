@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// HostLimiter bounds the number of in-flight requests and the request rate per host,
+// so that checking the links of one page never floods a single origin
+type HostLimiter struct {
+	mu            sync.Mutex
+	hosts         map[string]*hostState
+	maxConcurrent int
+	interval      time.Duration
+}
+
+// hostState tracks the concurrency slots and the next permitted start time of one host
+type hostState struct {
+	slots chan struct{}
+
+	// users counts the callers holding or waiting for the host; guarded by HostLimiter.mu
+	users int
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// idle reports whether nobody uses the host and forgetting it would not let a request
+// start earlier than the rate limit allows. The caller must hold HostLimiter.mu.
+func (s *hostState) idle(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.users == 0 && !s.next.After(now)
+}
+
+// NewHostLimiter creates a HostLimiter allowing maxConcurrent requests and rps
+// requests per second to each host. Non-positive values disable the respective limit.
+func NewHostLimiter(maxConcurrent int, rps float64) *HostLimiter {
+	var interval time.Duration
+	if rps > 0 {
+		interval = time.Duration(float64(time.Second) / rps)
+	}
+
+	return &HostLimiter{
+		hosts:         make(map[string]*hostState),
+		maxConcurrent: maxConcurrent,
+		interval:      interval,
+	}
+}
+
+// Acquire blocks until a request to host may start and returns a function that
+// must be called once the request has finished
+func (l *HostLimiter) Acquire(ctx context.Context, host string) (func(), error) {
+	state := l.state(host)
+
+	if state.slots != nil {
+		select {
+		case state.slots <- struct{}{}:
+		case <-ctx.Done():
+			l.leave(host, state)
+			return nil, ctx.Err()
+		}
+	}
+	release := func() {
+		if state.slots != nil {
+			<-state.slots
+		}
+		l.leave(host, state)
+	}
+
+	if err := l.waitTurn(ctx, state); err != nil {
+		release()
+		return nil, err
+	}
+
+	return release, nil
+}
+
+// state returns the limiter state of host and registers the caller as one of its
+// users, creating the state on first use. Idle hosts are forgotten along the way,
+// so the map only holds hosts in use or still inside their rate-limit interval.
+func (l *HostLimiter) state(host string) *hostState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state, ok := l.hosts[host]
+	if !ok {
+		now := time.Now()
+		for name, other := range l.hosts {
+			if other.idle(now) {
+				delete(l.hosts, name)
+			}
+		}
+
+		state = &hostState{}
+		if l.maxConcurrent > 0 {
+			state.slots = make(chan struct{}, l.maxConcurrent)
+		}
+		l.hosts[host] = state
+	}
+	state.users++
+	return state
+}
+
+// leave unregisters a user of host and forgets the host once it is idle
+func (l *HostLimiter) leave(host string, state *hostState) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state.users--
+	if state.idle(time.Now()) {
+		delete(l.hosts, host)
+	}
+}
+
+// waitTurn reserves the next start time for the host and sleeps until it arrives
+func (l *HostLimiter) waitTurn(ctx context.Context, state *hostState) error {
+	if l.interval <= 0 {
+		return nil
+	}
+
+	state.mu.Lock()
+	now := time.Now()
+	start := state.next
+	if start.Before(now) {
+		start = now
+	}
+	state.next = start.Add(l.interval)
+	state.mu.Unlock()
+
+	wait := time.Until(start)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
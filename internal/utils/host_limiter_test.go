@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostLimiter_BoundsConcurrencyPerHost(t *testing.T) {
+	limiter := NewHostLimiter(2, 0)
+
+	var inFlight, maxInFlight int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := limiter.Acquire(context.Background(), "example.com")
+			if !assert.NoError(t, err) {
+				return
+			}
+			current := atomic.AddInt32(&inFlight, 1)
+			for {
+				seen := atomic.LoadInt32(&maxInFlight)
+				if current <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, current) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+			release()
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, maxInFlight, int32(2), "No more than 2 requests should run against one host")
+}
+
+func TestHostLimiter_SpacesRequestsPerHost(t *testing.T) {
+	limiter := NewHostLimiter(0, 50) // one request every 20ms
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		release, err := limiter.Acquire(context.Background(), "example.com")
+		assert.NoError(t, err)
+		release()
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 55*time.Millisecond, "Requests to one host should be rate limited")
+}
+
+func TestHostLimiter_HostsAreIndependent(t *testing.T) {
+	limiter := NewHostLimiter(1, 0)
+
+	releaseA, err := limiter.Acquire(context.Background(), "a.example.com")
+	assert.NoError(t, err)
+	defer releaseA()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	releaseB, err := limiter.Acquire(ctx, "b.example.com")
+	assert.NoError(t, err, "A busy host must not block other hosts")
+	releaseB()
+}
+
+func TestHostLimiter_AcquireHonorsContext(t *testing.T) {
+	limiter := NewHostLimiter(1, 0)
+
+	release, err := limiter.Acquire(context.Background(), "example.com")
+	assert.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = limiter.Acquire(ctx, "example.com")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestHostLimiter_ForgetsIdleHosts(t *testing.T) {
+	limiter := NewHostLimiter(2, 0)
+
+	for i := 0; i < 100; i++ {
+		release, err := limiter.Acquire(context.Background(), fmt.Sprintf("host%d.example.com", i))
+		assert.NoError(t, err)
+		release()
+	}
+
+	assert.Empty(t, limiter.hosts, "Released hosts should not be kept")
+}
+
+func TestHostLimiter_KeepsHostsUntilTheirIntervalPasses(t *testing.T) {
+	limiter := NewHostLimiter(0, 20) // one request every 50ms
+
+	release, err := limiter.Acquire(context.Background(), "a.example.com")
+	assert.NoError(t, err)
+	release()
+	assert.Contains(t, limiter.hosts, "a.example.com", "Forgetting the host would skip its rate limit")
+
+	time.Sleep(60 * time.Millisecond)
+	release, err = limiter.Acquire(context.Background(), "b.example.com")
+	assert.NoError(t, err)
+	defer release()
+
+	assert.NotContains(t, limiter.hosts, "a.example.com", "Hosts past their interval should be forgotten")
+	assert.Contains(t, limiter.hosts, "b.example.com")
+}
@@ -3,7 +3,6 @@ package utils
 import (
 	"testing"
 
-	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -11,14 +10,14 @@ func TestExtractTitle(t *testing.T) {
 	htmlContent := `<html><head><title>Test Page</title></head><body></body></html>`
 	expectedTitle := "Test Page"
 
-	title := ExtractTitle(htmlContent)
+	title := ExtractTitle(mustParse(t, htmlContent))
 	assert.Equal(t, expectedTitle, title, "Extracted title should match expected value")
 }
 
 func TestExtractTitle_Empty(t *testing.T) {
 	htmlContent := `<html><head></head><body></body></html>`
 
-	title := ExtractTitle(htmlContent)
+	title := ExtractTitle(mustParse(t, htmlContent))
 	assert.Equal(t, "", title, "Extracted title should be empty when no title tag exists")
 }
 
@@ -29,12 +28,14 @@ func TestDetectHTMLVersion(t *testing.T) {
 	}{
 		{"<!DOCTYPE HTML PUBLIC \"-//W3C//DTD HTML 4.01//EN\">", "HTML 4.01"},
 		{"<!DOCTYPE HTML PUBLIC \"-//W3C//DTD HTML 3.2 Final//EN\">", "HTML 3.2"},
+		{"<!DOCTYPE HTML PUBLIC \"-//W3C//DTD HTML 4.01 Transitional//EN\">", "HTML 4.01"},
+		{"<!DOCTYPE html PUBLIC \"-//W3C//DTD XHTML 1.1//EN\">", "XHTML 1.1"},
 		{"<!DOCTYPE html>", "HTML5"},
 		{"<html><head></head><body></body></html>", "Unknown HTML version"},
 	}
 
 	for _, testCase := range testCases {
-		version := DetectHTMLVersion(testCase.htmlContent)
+		version := DetectHTMLVersion(mustParse(t, testCase.htmlContent))
 		assert.Equal(t, testCase.expected, version, "Detected HTML version should match expected value")
 	}
 }
@@ -53,45 +54,50 @@ func TestCountHeadings(t *testing.T) {
 		"h3": 1,
 	}
 
-	headings := CountHeadings(htmlContent)
+	headings := CountHeadings(mustParse(t, htmlContent))
 	assert.Equal(t, expected, headings, "Headings count should match expected values")
 }
 
-func TestCountLinksConcurrently(t *testing.T) {
-	httpmock.Activate()
-	defer httpmock.DeactivateAndReset()
-
-	// Mock HTTP responses
-	httpmock.RegisterResponder("HEAD", "http://example.com",
-		httpmock.NewStringResponder(200, ""))
-	httpmock.RegisterResponder("HEAD", "http://internal-site.com",
-		httpmock.NewStringResponder(200, ""))
-	httpmock.RegisterResponder("HEAD", "/internal",
-		httpmock.NewStringResponder(200, ""))
-	httpmock.RegisterResponder("HEAD", "https://broken-link-test.com/",
-		httpmock.NewStringResponder(404, ""))
-
-	htmlContent := `<html>
-		<body>
-			<a href="http://example.com">External Link</a>
-			<a href="http://internal-site.com">Internal Link</a>
-			<a href="/internal">Internal Link 2</a>
-			<a href="https://broken-link-test.com/">Broken Link</a>
-		</body>
-	</html>`
-
-	internal, external, inaccessible, err := CountLinksConcurrently("http://internal-site.com", htmlContent)
-
-	assert.NoError(t, err, "Should not return an error")
-	assert.Equal(t, 2, internal, "Should count 1 internal link")
-	assert.Equal(t, 2, external, "Should count 1 external link")
-	assert.Equal(t, 1, inaccessible, "Should count 1 inaccessible link")
-}
-
 func TestContainsLoginForm(t *testing.T) {
 	htmlWithLogin := `<html><body><form><input type="password"></form></body></html>`
 	htmlWithoutLogin := `<html><body><form><input type="text"></form></body></html>`
 
-	assert.True(t, ContainsLoginForm(htmlWithLogin), "Should detect login form")
-	assert.False(t, ContainsLoginForm(htmlWithoutLogin), "Should not detect login form")
-}
\ No newline at end of file
+	assert.True(t, ContainsLoginForm(mustParse(t, htmlWithLogin)), "Should detect login form")
+	assert.False(t, ContainsLoginForm(mustParse(t, htmlWithoutLogin)), "Should not detect login form")
+}
+
+func TestContainsLoginForm_AttributeVariants(t *testing.T) {
+	variants := []string{
+		`<form><input type='password'></form>`,
+		`<form><input type=password></form>`,
+		`<form><INPUT TYPE=PASSWORD></form>`,
+	}
+
+	for _, variant := range variants {
+		assert.True(t, ContainsLoginForm(mustParse(t, variant)), "Should detect login form in %s", variant)
+	}
+}
+
+func TestDetectHTMLVersion_IgnoresDoctypeInComment(t *testing.T) {
+	htmlContent := `<html><body><!-- <!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01//EN"> --></body></html>`
+
+	assert.Equal(t, "Unknown HTML version", DetectHTMLVersion(mustParse(t, htmlContent)))
+}
+
+func TestContainsLoginForm_IgnoresSignupForms(t *testing.T) {
+	htmlContent := `<form action="/register">
+		<input type="email" name="email">
+		<input type="password" autocomplete="new-password">
+		<input type="password" autocomplete="new-password">
+		<button>Sign up</button>
+	</form>`
+
+	assert.False(t, ContainsLoginForm(mustParse(t, htmlContent)), "Registration forms are not login forms")
+}
+
+func TestCountHeadings_IgnoresOtherTags(t *testing.T) {
+	htmlContent := `<html><head></head><body><h1>Title</h1><hr><h7>Not a heading</h7></body></html>`
+
+	headings := CountHeadings(mustParse(t, htmlContent))
+	assert.Equal(t, map[string]int{"h1": 1}, headings, "Only h1–h6 should be counted")
+}
@@ -0,0 +1,412 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/uikee/web-analyzer-service/config"
+	"github.com/uikee/web-analyzer-service/internal/fetcher"
+)
+
+const (
+	defaultLinkCheckConcurrency = 20
+	defaultRetryBaseDelay       = 500 * time.Millisecond
+	defaultRetryMaxDelay        = 10 * time.Second
+)
+
+// Link types reported in LinkResult.Type
+const (
+	LinkTypeInternal = "internal"
+	LinkTypeExternal = "external"
+)
+
+// Error kinds reported by the link checker in addition to fetcher.ClassifyError kinds
+const (
+	ErrorKindHTTPStatus         = "http_status"
+	ErrorKindInvalidURL         = "invalid_url"
+	ErrorKindUnsupportedScheme  = "unsupported_scheme"
+	ErrorKindUnresolvedRelative = "unresolved_relative"
+)
+
+// LinkResult describes a single <a href> found on the page and the outcome of its check
+type LinkResult struct {
+	Href       string `json:"href"`
+	URL        string `json:"url"`
+	Type       string `json:"type"`
+	Accessible bool   `json:"accessible"`
+	StatusCode int    `json:"status_code,omitempty"`
+	ErrorKind  string `json:"error_kind,omitempty"`
+	Method     string `json:"method,omitempty"`
+	Attempts   int    `json:"attempts,omitempty"`
+	LatencyMs  int64  `json:"latency_ms"`
+	RedirectTo string `json:"redirect_to,omitempty"`
+	AnchorText string `json:"anchor_text"`
+}
+
+// LinkSummary aggregates link results into the counters reported by the service
+type LinkSummary struct {
+	Internal     int
+	External     int
+	Inaccessible int
+}
+
+// SummarizeLinks counts internal, external and inaccessible links. Links that were
+// skipped because they cannot be fetched (e.g. mailto:, or a relative link in a
+// document without a base URL) are not counted as inaccessible.
+func SummarizeLinks(results []LinkResult) LinkSummary {
+	var summary LinkSummary
+	for _, result := range results {
+		if result.Type == LinkTypeInternal {
+			summary.Internal++
+		} else {
+			summary.External++
+		}
+		if !result.Accessible && !skipped(result.ErrorKind) {
+			summary.Inaccessible++
+		}
+	}
+	return summary
+}
+
+// skipped reports whether errorKind marks a link that was never checked
+func skipped(errorKind string) bool {
+	return errorKind == ErrorKindUnsupportedScheme || errorKind == ErrorKindUnresolvedRelative
+}
+
+// LinkChecker classifies links and checks their accessibility through a Fetcher.
+// A single LinkChecker is shared by all analyses, so its limits apply process-wide.
+type LinkChecker struct {
+	fetcher     fetcher.Fetcher
+	concurrency int
+	slots       chan struct{}
+	hostLimiter *HostLimiter
+
+	maxRetries     int
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
+}
+
+// NewLinkChecker creates a new LinkChecker that issues requests through the given Fetcher
+func NewLinkChecker(f fetcher.Fetcher, cfg config.LinkCheckConfig) *LinkChecker {
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultLinkCheckConcurrency
+	}
+
+	retryBaseDelay := cfg.RetryBaseDelay
+	if retryBaseDelay <= 0 {
+		retryBaseDelay = defaultRetryBaseDelay
+	}
+
+	retryMaxDelay := cfg.RetryMaxDelay
+	if retryMaxDelay <= 0 {
+		retryMaxDelay = defaultRetryMaxDelay
+	}
+
+	return &LinkChecker{
+		fetcher:        f,
+		concurrency:    concurrency,
+		slots:          make(chan struct{}, concurrency),
+		hostLimiter:    NewHostLimiter(cfg.PerHostConcurrency, cfg.PerHostRPS),
+		maxRetries:     max(cfg.MaxRetries, 0),
+		retryBaseDelay: retryBaseDelay,
+		retryMaxDelay:  retryMaxDelay,
+	}
+}
+
+// LinkCheckOptions tunes the link checks of a single analysis. Zero values keep
+// the LinkChecker's configured behavior.
+type LinkCheckOptions struct {
+	// Timeout bounds each request made to check a link
+	Timeout time.Duration
+
+	// MaxRetries overrides the configured number of retries when set
+	MaxRetries *int
+
+	// OnProgress, if set, is called with the number of links checked so far and
+	// the total once the links are collected and after every check. It is called
+	// from the worker goroutines.
+	OnProgress func(checked, total int)
+}
+
+// CheckLinks classifies and checks every link on the page using a bounded pool of
+// workers. pageURL should be the URL the page was finally served from; links
+// resolve against it, or against <base href> when the page has one. Results are
+// returned in document order. Canceling ctx stops in-flight checks and makes
+// CheckLinks return the context error.
+func (lc *LinkChecker) CheckLinks(ctx context.Context, doc *Document, pageURL string) ([]LinkResult, error) {
+	return lc.CheckLinksWithOptions(ctx, doc, pageURL, LinkCheckOptions{})
+}
+
+// CheckLinksWithOptions is CheckLinks with per-analysis options
+func (lc *LinkChecker) CheckLinksWithOptions(ctx context.Context, doc *Document, pageURL string, opts LinkCheckOptions) ([]LinkResult, error) {
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		config.Logger.Error().Err(err).Str("url", pageURL).Msg("Failed to parse page URL while checking links")
+		return nil, err
+	}
+	base := doc.BaseURL(page)
+
+	var results []LinkResult
+	for _, anchor := range doc.FindAll("a") {
+		if href, ok := attr(anchor, "href"); ok {
+			results = append(results, LinkResult{
+				Href:       href,
+				AnchorText: nodeText(anchor),
+			})
+		}
+	}
+
+	var checked atomic.Int64
+	reportProgress := func(n int64) {
+		if opts.OnProgress != nil {
+			opts.OnProgress(int(n), len(results))
+		}
+	}
+	reportProgress(0)
+
+	// Each worker only writes the result at the index it was handed
+	jobs := make(chan int)
+	workers := min(lc.concurrency, len(results))
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				lc.checkLink(ctx, page, base, opts, &results[index])
+				reportProgress(checked.Add(1))
+			}
+		}()
+	}
+
+dispatch:
+	for index := range results {
+		select {
+		case jobs <- index:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		config.Logger.Warn().Err(err).Str("url", pageURL).Msg("Link analysis stopped before completion")
+		return nil, err
+	}
+
+	summary := SummarizeLinks(results)
+	config.Logger.Info().Int("internal_links", summary.Internal).Int("external_links", summary.External).Int("inaccessible_links", summary.Inaccessible).Msg("Link analysis completed successfully")
+	return results, nil
+}
+
+// checkLink resolves a single link against base, classifies it relative to the
+// page's host and checks it, filling in result
+func (lc *LinkChecker) checkLink(ctx context.Context, page, base *url.URL, opts LinkCheckOptions, result *LinkResult) {
+	parsedLink, err := url.Parse(strings.TrimSpace(result.Href))
+	if err != nil {
+		result.Type = LinkTypeExternal
+		result.ErrorKind = ErrorKindInvalidURL
+		return
+	}
+	parsedLink = base.ResolveReference(parsedLink)
+	result.URL = parsedLink.String()
+
+	if parsedLink.Host == page.Host {
+		result.Type = LinkTypeInternal
+	} else {
+		result.Type = LinkTypeExternal
+	}
+
+	// Without an absolute page or base URL a relative link has nothing to resolve against
+	if parsedLink.Scheme == "" {
+		result.ErrorKind = ErrorKindUnresolvedRelative
+		return
+	}
+	if parsedLink.Scheme != "http" && parsedLink.Scheme != "https" {
+		result.ErrorKind = ErrorKindUnsupportedScheme
+		return
+	}
+
+	maxRetries := lc.maxRetries
+	if opts.MaxRetries != nil {
+		maxRetries = max(*opts.MaxRetries, 0)
+	}
+
+	// Many servers reject or drop HEAD, so fall back to a ranged GET before giving
+	// up. The GET is only retried as often as the HEAD attempts left room for.
+	attempt := lc.probe(ctx, http.MethodHead, parsedLink.Host, maxRetries, opts.Timeout, result)
+	if headRejected(attempt) {
+		attempt = lc.probe(ctx, http.MethodGet, parsedLink.Host, max(maxRetries-result.Attempts, 0), opts.Timeout, result)
+	}
+	result.LatencyMs = attempt.latency.Milliseconds()
+
+	if attempt.err != nil {
+		result.ErrorKind = fetcher.ClassifyError(attempt.err)
+		config.Logger.Info().Str("link", result.URL).Str("method", result.Method).Str("error_kind", result.ErrorKind).Msg("Inaccessible link")
+		return
+	}
+
+	result.StatusCode = attempt.resp.StatusCode
+	if attempt.resp.URL != "" && attempt.resp.URL != result.URL {
+		result.RedirectTo = attempt.resp.URL
+	}
+
+	// 416 only means the single byte we asked for is out of range, so the resource exists
+	if result.StatusCode >= 400 && !(result.Method == http.MethodGet && result.StatusCode == http.StatusRequestedRangeNotSatisfiable) {
+		result.ErrorKind = ErrorKindHTTPStatus
+		config.Logger.Info().Str("link", result.URL).Str("method", result.Method).Int("status", result.StatusCode).Msg("Inaccessible link")
+		return
+	}
+	result.Accessible = true
+}
+
+// linkAttempt is the outcome of a single request made while checking a link
+type linkAttempt struct {
+	resp    *fetcher.Response
+	err     error
+	latency time.Duration
+}
+
+// probe requests the link with the given method, retrying transient failures up
+// to maxRetries times with exponential backoff and honoring Retry-After
+func (lc *LinkChecker) probe(ctx context.Context, method, host string, maxRetries int, timeout time.Duration, result *LinkResult) linkAttempt {
+	result.Method = method
+
+	for retry := 0; ; retry++ {
+		attempt := lc.send(ctx, method, host, result.URL, timeout)
+		result.Attempts++
+
+		if retry >= maxRetries || !isTransient(attempt) {
+			return attempt
+		}
+
+		delay, ok := lc.retryDelay(retry, attempt.resp)
+		if !ok {
+			return attempt
+		}
+
+		config.Logger.Debug().Str("link", result.URL).Str("method", method).Dur("delay", delay).Msg("Retrying link check")
+		if err := sleepContext(ctx, delay); err != nil {
+			return attempt
+		}
+	}
+}
+
+// send performs one request while holding a global slot and respecting per-host
+// limits. A zero timeout uses the fetcher's default.
+func (lc *LinkChecker) send(ctx context.Context, method, host, link string, timeout time.Duration) linkAttempt {
+	// Wait for the host first, so that a slow or rate-limited host does not hold
+	// global slots other hosts could use
+	release, err := lc.hostLimiter.Acquire(ctx, host)
+	if err != nil {
+		return linkAttempt{err: err}
+	}
+	defer release()
+
+	select {
+	case lc.slots <- struct{}{}:
+	case <-ctx.Done():
+		return linkAttempt{err: ctx.Err()}
+	}
+	defer func() { <-lc.slots }()
+
+	req := fetcher.Request{Method: method, URL: link, Timeout: timeout, DiscardBody: true}
+	if method == http.MethodGet {
+		req.Header = http.Header{"Range": []string{"bytes=0-0"}}
+	}
+
+	start := time.Now()
+	resp, err := lc.fetcher.Fetch(ctx, req)
+	return linkAttempt{resp: resp, err: err, latency: time.Since(start)}
+}
+
+// retryDelay returns how long to wait before the next retry. Retry-After takes
+// precedence over the backoff; a Retry-After beyond the maximum delay stops retrying.
+func (lc *LinkChecker) retryDelay(retry int, resp *fetcher.Response) (time.Duration, bool) {
+	if resp != nil {
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return retryAfter, retryAfter <= lc.retryMaxDelay
+		}
+	}
+
+	delay := lc.retryBaseDelay << retry
+	if delay > lc.retryMaxDelay || delay <= 0 {
+		delay = lc.retryMaxDelay
+	}
+	return delay, true
+}
+
+// isTransient reports whether a failed attempt is worth retrying
+func isTransient(attempt linkAttempt) bool {
+	if attempt.err != nil {
+		return fetcher.ClassifyError(attempt.err) == fetcher.ErrorKindTimeout
+	}
+
+	switch attempt.resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// headRejected reports whether the server refused or dropped a HEAD request
+// in a way that a GET might not be. A timeout is not a rejection; a GET to the
+// same slow server would only time out again.
+func headRejected(attempt linkAttempt) bool {
+	if attempt.err != nil {
+		switch fetcher.ClassifyError(attempt.err) {
+		case fetcher.ErrorKindConnection, fetcher.ErrorKindNetwork:
+			return true
+		default:
+			return false
+		}
+	}
+
+	switch attempt.resp.StatusCode {
+	case http.StatusBadRequest, http.StatusForbidden, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+
+	return 0, false
+}
+
+// sleepContext waits for the given duration or until the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,427 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uikee/web-analyzer-service/config"
+	"github.com/uikee/web-analyzer-service/internal/fetcher"
+)
+
+// stubFetcher answers requests from a fixed URL-to-status table and records what it was asked
+type stubFetcher struct {
+	mu       sync.Mutex
+	statuses map[string]int
+	requests []fetcher.Request
+}
+
+func (s *stubFetcher) Fetch(ctx context.Context, req fetcher.Request) (*fetcher.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+
+	status, ok := s.statuses[req.URL]
+	if !ok {
+		return nil, fetcher.ErrRequestFailed
+	}
+	return &fetcher.Response{URL: req.URL, StatusCode: status}, nil
+}
+
+func TestCheckLinks(t *testing.T) {
+	// Mock HTTP responses
+	stub := &stubFetcher{statuses: map[string]int{
+		"http://example.com":                200,
+		"http://internal-site.com":          200,
+		"http://internal-site.com/internal": 200,
+		"https://broken-link-test.com/":     404,
+	}}
+
+	htmlContent := `<html>
+		<body>
+			<a href="http://example.com">External Link</a>
+			<a href="http://internal-site.com">Internal Link</a>
+			<a href="/internal">Internal Link 2</a>
+			<a href="https://broken-link-test.com/">Broken Link</a>
+		</body>
+	</html>`
+
+	results, err := NewLinkChecker(stub, config.LinkCheckConfig{}).CheckLinks(context.Background(), mustParse(t, htmlContent), "http://internal-site.com")
+	summary := SummarizeLinks(results)
+
+	assert.NoError(t, err, "Should not return an error")
+	assert.Equal(t, 2, summary.Internal, "Should count 2 internal links")
+	assert.Equal(t, 2, summary.External, "Should count 2 external links")
+	assert.Equal(t, 1, summary.Inaccessible, "Should count 1 inaccessible link")
+}
+
+func TestCheckLinks_DetailedResults(t *testing.T) {
+	redirecting := fetcher.FetchFunc(func(ctx context.Context, req fetcher.Request) (*fetcher.Response, error) {
+		switch req.URL {
+		case "http://example.com/old":
+			return &fetcher.Response{URL: "http://example.com/new", StatusCode: 200}, nil
+		case "https://broken.example.org/":
+			return &fetcher.Response{URL: req.URL, StatusCode: 500}, nil
+		default:
+			return nil, fetcher.ErrRequestFailed
+		}
+	})
+
+	htmlContent := `<body>
+		<a href="/old">  Old
+			page </a>
+		<a href="https://broken.example.org/">Broken <b>link</b></a>
+		<a href="mailto:team@example.com">Mail us</a>
+	</body>`
+
+	results, err := NewLinkChecker(redirecting, config.LinkCheckConfig{}).CheckLinks(context.Background(), mustParse(t, htmlContent), "http://example.com/")
+
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+
+	assert.Equal(t, LinkResult{
+		Href:       "/old",
+		URL:        "http://example.com/old",
+		Type:       LinkTypeInternal,
+		Accessible: true,
+		StatusCode: 200,
+		Method:     http.MethodHead,
+		Attempts:   1,
+		LatencyMs:  results[0].LatencyMs,
+		RedirectTo: "http://example.com/new",
+		AnchorText: "Old page",
+	}, results[0])
+
+	assert.Equal(t, LinkTypeExternal, results[1].Type)
+	assert.False(t, results[1].Accessible)
+	assert.Equal(t, 500, results[1].StatusCode)
+	assert.Equal(t, ErrorKindHTTPStatus, results[1].ErrorKind)
+	assert.Equal(t, "Broken link", results[1].AnchorText)
+
+	assert.Equal(t, ErrorKindUnsupportedScheme, results[2].ErrorKind)
+	assert.Equal(t, 1, SummarizeLinks(results).Inaccessible, "Unsupported schemes are not counted as inaccessible")
+}
+
+func TestCheckLinks_ResolvesAgainstBaseHref(t *testing.T) {
+	stub := &stubFetcher{statuses: map[string]int{
+		"https://cdn.example.com/docs/guide": 200,
+		"https://example.com/about":          200,
+	}}
+
+	htmlContent := `<head><base href="https://cdn.example.com/docs/"></head>
+		<body><a href="guide">Guide</a><a href="https://example.com/about">About</a></body>`
+
+	results, err := NewLinkChecker(stub, config.LinkCheckConfig{}).CheckLinks(context.Background(), mustParse(t, htmlContent), "https://example.com/")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/docs/guide", results[0].URL)
+	assert.Equal(t, LinkTypeExternal, results[0].Type, "Links are classified relative to the page, not the base URL")
+	assert.Equal(t, LinkTypeInternal, results[1].Type)
+	assert.Equal(t, 0, SummarizeLinks(results).Inaccessible)
+}
+
+func TestCheckLinks_UnresolvedRelativeLinks(t *testing.T) {
+	stub := &stubFetcher{statuses: map[string]int{"https://example.com/about": 200}}
+
+	htmlContent := `<body><a href="/rel">Relative</a><a href="https://example.com/about">About</a></body>`
+
+	results, err := NewLinkChecker(stub, config.LinkCheckConfig{}).CheckLinks(context.Background(), mustParse(t, htmlContent), "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/rel", results[0].URL)
+	assert.False(t, results[0].Accessible)
+	assert.Equal(t, ErrorKindUnresolvedRelative, results[0].ErrorKind)
+	assert.Zero(t, results[0].Attempts, "Unresolved links are never requested")
+	assert.True(t, results[1].Accessible)
+	assert.Equal(t, 0, SummarizeLinks(results).Inaccessible, "Unresolved relative links are not counted as inaccessible")
+}
+
+func TestCheckLinks_UsesHeadRequests(t *testing.T) {
+	stub := &stubFetcher{statuses: map[string]int{"http://example.com/a": 200}}
+
+	_, err := NewLinkChecker(stub, config.LinkCheckConfig{}).CheckLinks(context.Background(), mustParse(t, `<a href="/a">A</a>`), "http://example.com")
+
+	assert.NoError(t, err)
+	assert.Len(t, stub.requests, 1)
+	assert.Equal(t, http.MethodHead, stub.requests[0].Method)
+}
+
+func TestCheckLinks_BoundedConcurrency(t *testing.T) {
+	var inFlight, maxInFlight int32
+	slow := fetcher.FetchFunc(func(ctx context.Context, req fetcher.Request) (*fetcher.Response, error) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(&maxInFlight)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, current) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		return &fetcher.Response{URL: req.URL, StatusCode: 200}, nil
+	})
+
+	var page strings.Builder
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&page, `<a href="http://host%d.example.com/">link</a>`, i)
+	}
+
+	checker := NewLinkChecker(slow, config.LinkCheckConfig{Concurrency: 3})
+	results, err := checker.CheckLinks(context.Background(), mustParse(t, page.String()), "http://example.com")
+
+	assert.NoError(t, err)
+	assert.Len(t, results, 50)
+	assert.Equal(t, 50, SummarizeLinks(results).External, "Every link should be counted exactly once")
+	assert.LessOrEqual(t, maxInFlight, int32(3), "No more than 3 link checks should run at once")
+}
+
+func TestCheckLinks_RateLimitedHostDoesNotHoldSlots(t *testing.T) {
+	firstSent := make(chan struct{}, 1)
+	f := fetcher.FetchFunc(func(ctx context.Context, req fetcher.Request) (*fetcher.Response, error) {
+		if req.URL == "http://slow.example.com/1" {
+			firstSent <- struct{}{}
+		}
+		return &fetcher.Response{URL: req.URL, StatusCode: 200}, nil
+	})
+	checker := NewLinkChecker(f, config.LinkCheckConfig{Concurrency: 1, PerHostRPS: 2})
+
+	// The second link to slow.example.com waits 500ms for its turn
+	slowPage := `<a href="http://slow.example.com/1">1</a><a href="http://slow.example.com/2">2</a>`
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := checker.CheckLinks(context.Background(), mustParse(t, slowPage), "http://example.com")
+		assert.NoError(t, err)
+	}()
+	<-firstSent
+	time.Sleep(50 * time.Millisecond) // let the second link start waiting
+
+	start := time.Now()
+	results, err := checker.CheckLinks(context.Background(), mustParse(t, `<a href="http://fast.example.com/">fast</a>`), "http://example.com")
+
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Less(t, time.Since(start), 250*time.Millisecond, "Waiting for a rate-limited host must not hold the only global slot")
+	<-done
+}
+
+// scriptedFetcher replies with queued statuses per method and records every request
+type scriptedFetcher struct {
+	mu       sync.Mutex
+	replies  map[string][]*fetcher.Response
+	requests []fetcher.Request
+}
+
+func (s *scriptedFetcher) Fetch(ctx context.Context, req fetcher.Request) (*fetcher.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+
+	queue := s.replies[req.Method]
+	if len(queue) == 0 {
+		return nil, fetcher.ErrRequestFailed
+	}
+	s.replies[req.Method] = queue[1:]
+	return queue[0], nil
+}
+
+func fastRetryConfig() config.LinkCheckConfig {
+	return config.LinkCheckConfig{MaxRetries: 2, RetryBaseDelay: time.Millisecond, RetryMaxDelay: 50 * time.Millisecond}
+}
+
+func TestCheckLinks_FallsBackToRangedGet(t *testing.T) {
+	scripted := &scriptedFetcher{replies: map[string][]*fetcher.Response{
+		http.MethodHead: {{StatusCode: http.StatusMethodNotAllowed}},
+		http.MethodGet:  {{StatusCode: http.StatusPartialContent}},
+	}}
+
+	results, err := NewLinkChecker(scripted, fastRetryConfig()).CheckLinks(context.Background(), mustParse(t, `<a href="/a">A</a>`), "http://example.com")
+
+	assert.NoError(t, err)
+	assert.True(t, results[0].Accessible)
+	assert.Equal(t, http.MethodGet, results[0].Method, "Verdict should come from the GET fallback")
+	assert.Equal(t, 2, results[0].Attempts)
+	assert.Len(t, scripted.requests, 2)
+	assert.Equal(t, "bytes=0-0", scripted.requests[1].Header.Get("Range"))
+}
+
+// timeoutFetcher times out every HEAD request and answers GET with a fixed status
+type timeoutFetcher struct {
+	mu       sync.Mutex
+	status   int
+	requests []fetcher.Request
+}
+
+func (s *timeoutFetcher) Fetch(ctx context.Context, req fetcher.Request) (*fetcher.Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+
+	if req.Method == http.MethodHead {
+		return nil, fmt.Errorf("%w: %w", fetcher.ErrRequestFailed, context.DeadlineExceeded)
+	}
+	return &fetcher.Response{URL: req.URL, StatusCode: s.status}, nil
+}
+
+func TestCheckLinks_HeadTimeoutDoesNotFallBackToGet(t *testing.T) {
+	slow := &timeoutFetcher{status: http.StatusServiceUnavailable}
+
+	results, err := NewLinkChecker(slow, fastRetryConfig()).CheckLinks(context.Background(), mustParse(t, `<a href="/a">A</a>`), "http://example.com")
+
+	assert.NoError(t, err)
+	assert.False(t, results[0].Accessible)
+	assert.Equal(t, fetcher.ErrorKindTimeout, results[0].ErrorKind)
+	assert.Equal(t, http.MethodHead, results[0].Method)
+	assert.Equal(t, 3, results[0].Attempts, "One attempt plus two retries, all HEAD")
+	assert.Len(t, slow.requests, 3)
+}
+
+func TestCheckLinks_FallbackGetSharesRetryBudget(t *testing.T) {
+	scripted := &scriptedFetcher{replies: map[string][]*fetcher.Response{
+		http.MethodHead: {{StatusCode: 503}, {StatusCode: 405}},
+		http.MethodGet:  {{StatusCode: 503}, {StatusCode: 503}, {StatusCode: 503}},
+	}}
+
+	results, err := NewLinkChecker(scripted, fastRetryConfig()).CheckLinks(context.Background(), mustParse(t, `<a href="/a">A</a>`), "http://example.com")
+
+	assert.NoError(t, err)
+	assert.False(t, results[0].Accessible)
+	assert.Equal(t, http.MethodGet, results[0].Method)
+	assert.Equal(t, 3, results[0].Attempts, "Two HEAD attempts leave no retries for the GET")
+	assert.Len(t, scripted.requests, 3)
+}
+
+func TestCheckLinks_RetriesTransientFailures(t *testing.T) {
+	scripted := &scriptedFetcher{replies: map[string][]*fetcher.Response{
+		http.MethodHead: {{StatusCode: http.StatusServiceUnavailable}, {StatusCode: http.StatusOK}},
+	}}
+
+	results, err := NewLinkChecker(scripted, fastRetryConfig()).CheckLinks(context.Background(), mustParse(t, `<a href="/a">A</a>`), "http://example.com")
+
+	assert.NoError(t, err)
+	assert.True(t, results[0].Accessible)
+	assert.Equal(t, http.MethodHead, results[0].Method)
+	assert.Equal(t, 2, results[0].Attempts)
+}
+
+func TestCheckLinks_GivesUpAfterMaxRetries(t *testing.T) {
+	scripted := &scriptedFetcher{replies: map[string][]*fetcher.Response{
+		http.MethodHead: {{StatusCode: 502}, {StatusCode: 502}, {StatusCode: 502}, {StatusCode: 200}},
+	}}
+
+	results, err := NewLinkChecker(scripted, fastRetryConfig()).CheckLinks(context.Background(), mustParse(t, `<a href="/a">A</a>`), "http://example.com")
+
+	assert.NoError(t, err)
+	assert.False(t, results[0].Accessible)
+	assert.Equal(t, 502, results[0].StatusCode)
+	assert.Equal(t, 3, results[0].Attempts, "One attempt plus two retries")
+}
+
+func TestCheckLinksWithOptions_OverridesRetriesAndTimeout(t *testing.T) {
+	scripted := &scriptedFetcher{replies: map[string][]*fetcher.Response{
+		http.MethodHead: {{StatusCode: 502}, {StatusCode: 200}},
+	}}
+	noRetries := 0
+
+	opts := LinkCheckOptions{Timeout: 2 * time.Second, MaxRetries: &noRetries}
+	results, err := NewLinkChecker(scripted, fastRetryConfig()).CheckLinksWithOptions(context.Background(), mustParse(t, `<a href="/a">A</a>`), "http://example.com", opts)
+
+	assert.NoError(t, err)
+	assert.False(t, results[0].Accessible)
+	assert.Equal(t, 1, results[0].Attempts, "Retries should be disabled for this analysis")
+	assert.Equal(t, 2*time.Second, scripted.requests[0].Timeout)
+}
+
+func TestCheckLinksWithOptions_ReportsProgress(t *testing.T) {
+	stub := &stubFetcher{statuses: map[string]int{
+		"http://example.com/a": 200,
+		"http://example.com/b": 200,
+		"http://example.com/c": 404,
+	}}
+
+	var mu sync.Mutex
+	var reports []int
+	opts := LinkCheckOptions{OnProgress: func(checked, total int) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, 3, total)
+		reports = append(reports, checked)
+	}}
+
+	_, err := NewLinkChecker(stub, config.LinkCheckConfig{}).CheckLinksWithOptions(context.Background(),
+		mustParse(t, `<a href="/a">A</a><a href="/b">B</a><a href="/c">C</a>`), "http://example.com", opts)
+
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int{0, 1, 2, 3}, reports)
+}
+
+func TestCheckLinks_HonorsRetryAfter(t *testing.T) {
+	tooManyRequests := &fetcher.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"0"}}}
+	scripted := &scriptedFetcher{replies: map[string][]*fetcher.Response{
+		http.MethodHead: {tooManyRequests, {StatusCode: http.StatusOK}},
+	}}
+
+	results, err := NewLinkChecker(scripted, fastRetryConfig()).CheckLinks(context.Background(), mustParse(t, `<a href="/a">A</a>`), "http://example.com")
+
+	assert.NoError(t, err)
+	assert.True(t, results[0].Accessible)
+	assert.Equal(t, 2, results[0].Attempts)
+}
+
+func TestCheckLinks_RetryAfterBeyondMaxDelayStopsRetrying(t *testing.T) {
+	tooManyRequests := &fetcher.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": []string{"3600"}}}
+	scripted := &scriptedFetcher{replies: map[string][]*fetcher.Response{
+		http.MethodHead: {tooManyRequests, {StatusCode: http.StatusOK}},
+	}}
+
+	results, err := NewLinkChecker(scripted, fastRetryConfig()).CheckLinks(context.Background(), mustParse(t, `<a href="/a">A</a>`), "http://example.com")
+
+	assert.NoError(t, err)
+	assert.False(t, results[0].Accessible)
+	assert.Equal(t, http.StatusTooManyRequests, results[0].StatusCode)
+	assert.Equal(t, 1, results[0].Attempts)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	delay, ok := parseRetryAfter("120")
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, delay)
+
+	delay, ok = parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.InDelta(t, time.Hour.Seconds(), delay.Seconds(), 2)
+
+	_, ok = parseRetryAfter("soon")
+	assert.False(t, ok)
+}
+
+func TestCheckLinks_StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var requests int32
+	blocking := fetcher.FetchFunc(func(ctx context.Context, req fetcher.Request) (*fetcher.Response, error) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			cancel()
+		}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	var page strings.Builder
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&page, `<a href="/page-%d">link</a>`, i)
+	}
+
+	checker := NewLinkChecker(blocking, config.LinkCheckConfig{Concurrency: 2})
+	results, err := checker.CheckLinks(ctx, mustParse(t, page.String()), "http://example.com")
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, results)
+	assert.Less(t, atomic.LoadInt32(&requests), int32(100), "Remaining links should not be checked after cancellation")
+}
@@ -0,0 +1,331 @@
+package utils
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// Rules reported for page metadata
+const (
+	RuleMetaDescriptionMissing   = "meta-description-missing"
+	RuleMetaDescriptionDuplicate = "meta-description-duplicate"
+	RuleMetaDescriptionTooLong   = "meta-description-too-long"
+	RuleMetaViewportMissing      = "meta-viewport-missing"
+	RuleMetaViewportNoZoom       = "meta-viewport-no-zoom"
+	RuleMetaCharsetMissing       = "meta-charset-missing"
+	RuleMetaCharsetDuplicate     = "meta-charset-duplicate"
+	RuleCanonicalMissing         = "canonical-missing"
+	RuleCanonicalDuplicate       = "canonical-duplicate"
+	RuleCanonicalInvalid         = "canonical-invalid"
+	RuleRobotsNoindex            = "robots-noindex"
+	RuleHTMLLangMissing          = "html-lang-missing"
+	RuleOpenGraphIncomplete      = "open-graph-incomplete"
+	RuleTwitterCardMissing       = "twitter-card-missing"
+	RuleIconMissing              = "icon-missing"
+)
+
+// maxDescriptionLength is roughly where search engines truncate descriptions
+const maxDescriptionLength = 160
+
+// requiredOpenGraph are the properties the Open Graph protocol requires
+var requiredOpenGraph = []string{"og:title", "og:type", "og:image", "og:url"}
+
+// Icon is a favicon, apple-touch icon or similar <link>
+type Icon struct {
+	Rel   string `json:"rel"`
+	Href  string `json:"href"`
+	Sizes string `json:"sizes,omitempty"`
+	Type  string `json:"type,omitempty"`
+}
+
+// Metadata is what the page says about itself in <head> and the response headers
+type Metadata struct {
+	Description string   `json:"description,omitempty"`
+	Keywords    []string `json:"keywords,omitempty"`
+	Viewport    string   `json:"viewport,omitempty"`
+	Charset     string   `json:"charset,omitempty"`
+	Canonical   string   `json:"canonical,omitempty"`
+	Robots      []string `json:"robots,omitempty"`
+	XRobotsTag  []string `json:"x_robots_tag,omitempty"`
+	Lang        string   `json:"lang,omitempty"`
+
+	// OpenGraph and Twitter map property names (e.g. "og:title") to the first value given
+	OpenGraph map[string]string `json:"open_graph,omitempty"`
+	Twitter   map[string]string `json:"twitter,omitempty"`
+	Icons     []Icon            `json:"icons,omitempty"`
+	Findings  []Finding         `json:"findings,omitempty"`
+}
+
+// ExtractMetadata collects the page's meta tags, canonical link, robots directives,
+// social cards, icons and language, and validates them. header is the response
+// header, used for X-Robots-Tag and the charset; it may be nil.
+func ExtractMetadata(doc *Document, pageURL string, header http.Header) Metadata {
+	page, err := url.Parse(pageURL)
+	if err != nil {
+		page = &url.URL{}
+	}
+	base := doc.BaseURL(page)
+
+	var metadata Metadata
+	var descriptions, viewports, charsets, canonicals []*html.Node
+
+	if root := doc.Find("html"); root != nil {
+		metadata.Lang, _ = attr(root, "lang")
+		metadata.Lang = strings.TrimSpace(metadata.Lang)
+	}
+
+	for _, meta := range doc.FindAll("meta") {
+		name, _ := attr(meta, "name")
+		property, _ := attr(meta, "property")
+		content, _ := attr(meta, "content")
+		name, property, content = strings.ToLower(strings.TrimSpace(name)), strings.ToLower(strings.TrimSpace(property)), strings.TrimSpace(content)
+
+		if charset, ok := attr(meta, "charset"); ok {
+			charsets = append(charsets, meta)
+			setOnce(&metadata.Charset, strings.TrimSpace(charset))
+			continue
+		}
+		if httpEquiv, _ := attr(meta, "http-equiv"); strings.EqualFold(strings.TrimSpace(httpEquiv), "content-type") {
+			if _, params, err := mime.ParseMediaType(content); err == nil && params["charset"] != "" {
+				charsets = append(charsets, meta)
+				setOnce(&metadata.Charset, params["charset"])
+			}
+			continue
+		}
+
+		switch {
+		case name == "description":
+			descriptions = append(descriptions, meta)
+			setOnce(&metadata.Description, content)
+		case name == "keywords":
+			if metadata.Keywords == nil {
+				metadata.Keywords = splitDirectives(content, false)
+			}
+		case name == "viewport":
+			viewports = append(viewports, meta)
+			setOnce(&metadata.Viewport, content)
+		case name == "robots":
+			metadata.Robots = append(metadata.Robots, splitDirectives(content, true)...)
+		case strings.HasPrefix(property, "og:"):
+			metadata.OpenGraph = setProperty(metadata.OpenGraph, property, content)
+		case strings.HasPrefix(name, "twitter:"):
+			metadata.Twitter = setProperty(metadata.Twitter, name, content)
+		case strings.HasPrefix(property, "twitter:"):
+			metadata.Twitter = setProperty(metadata.Twitter, property, content)
+		}
+	}
+
+	for _, link := range doc.FindAll("link") {
+		rel, _ := attr(link, "rel")
+		href, _ := attr(link, "href")
+		rels := strings.Fields(strings.ToLower(rel))
+
+		switch {
+		case slices.Contains(rels, "canonical"):
+			canonicals = append(canonicals, link)
+			if metadata.Canonical == "" {
+				if resolved, ok := resolveHref(base, href); ok {
+					metadata.Canonical = resolved
+				}
+			}
+		case hasIconRel(rels):
+			icon := Icon{Rel: strings.Join(rels, " ")}
+			icon.Href, _ = resolveHref(base, href)
+			icon.Sizes, _ = attr(link, "sizes")
+			icon.Type, _ = attr(link, "type")
+			metadata.Icons = append(metadata.Icons, icon)
+		}
+	}
+
+	if header != nil {
+		for _, value := range header.Values("X-Robots-Tag") {
+			metadata.XRobotsTag = append(metadata.XRobotsTag, splitDirectives(value, true)...)
+		}
+		if metadata.Charset == "" {
+			if _, params, err := mime.ParseMediaType(header.Get("Content-Type")); err == nil {
+				metadata.Charset = params["charset"]
+			}
+		}
+	}
+
+	metadata.Findings = validateMetadata(metadata, descriptions, viewports, charsets, canonicals)
+	return metadata
+}
+
+// validateMetadata reports missing, duplicate and malformed metadata
+func validateMetadata(m Metadata, descriptions, viewports, charsets, canonicals []*html.Node) []Finding {
+	var findings []Finding
+	add := func(rule, severity, message string, node *html.Node) {
+		finding := Finding{Rule: rule, Severity: severity, Message: message}
+		if node != nil {
+			finding.Path = CSSPath(node)
+		}
+		findings = append(findings, finding)
+	}
+
+	switch {
+	case len(descriptions) == 0 || m.Description == "":
+		add(RuleMetaDescriptionMissing, SeverityMedium, "Page has no meta description", nil)
+	case utf8.RuneCountInString(m.Description) > maxDescriptionLength:
+		add(RuleMetaDescriptionTooLong, SeverityLow,
+			fmt.Sprintf("Meta description is %d characters; keep it under %d", utf8.RuneCountInString(m.Description), maxDescriptionLength),
+			descriptions[0])
+	}
+	if len(descriptions) > 1 {
+		add(RuleMetaDescriptionDuplicate, SeverityLow, fmt.Sprintf("Page has %d meta descriptions", len(descriptions)), descriptions[1])
+	}
+
+	if len(viewports) == 0 {
+		add(RuleMetaViewportMissing, SeverityMedium, "Page has no viewport meta tag", nil)
+	} else if disablesZoom(m.Viewport) {
+		add(RuleMetaViewportNoZoom, SeverityMedium, "Viewport prevents users from zooming", viewports[0])
+	}
+
+	if m.Charset == "" {
+		add(RuleMetaCharsetMissing, SeverityLow, "Character encoding is declared neither in a meta tag nor in the Content-Type header", nil)
+	}
+	if len(charsets) > 1 {
+		add(RuleMetaCharsetDuplicate, SeverityLow, fmt.Sprintf("Page declares its charset %d times", len(charsets)), charsets[1])
+	}
+
+	switch {
+	case len(canonicals) == 0:
+		add(RuleCanonicalMissing, SeverityLow, "Page has no canonical link", nil)
+	case m.Canonical == "":
+		add(RuleCanonicalInvalid, SeverityMedium, "Canonical link has no valid href", canonicals[0])
+	}
+	if len(canonicals) > 1 {
+		add(RuleCanonicalDuplicate, SeverityMedium, fmt.Sprintf("Page has %d canonical links", len(canonicals)), canonicals[1])
+	}
+
+	if hasNoindex(m.Robots) {
+		add(RuleRobotsNoindex, SeverityInfo, "Robots meta tag keeps the page out of search indexes", nil)
+	} else if hasNoindex(m.XRobotsTag) {
+		add(RuleRobotsNoindex, SeverityInfo, "X-Robots-Tag header keeps the page out of search indexes", nil)
+	}
+
+	if m.Lang == "" {
+		add(RuleHTMLLangMissing, SeverityMedium, "The html element has no lang attribute", nil)
+	}
+
+	if len(m.OpenGraph) > 0 {
+		var missing []string
+		for _, property := range requiredOpenGraph {
+			if m.OpenGraph[property] == "" {
+				missing = append(missing, property)
+			}
+		}
+		if len(missing) > 0 {
+			add(RuleOpenGraphIncomplete, SeverityLow, "Open Graph is missing "+strings.Join(missing, ", "), nil)
+		}
+	} else {
+		add(RuleOpenGraphIncomplete, SeverityLow, "Page has no Open Graph properties", nil)
+	}
+
+	if m.Twitter["twitter:card"] == "" {
+		add(RuleTwitterCardMissing, SeverityInfo, "Page has no twitter:card meta tag", nil)
+	}
+
+	if len(m.Icons) == 0 {
+		add(RuleIconMissing, SeverityLow, "Page declares no favicon", nil)
+	}
+
+	return findings
+}
+
+// disablesZoom reports whether a viewport content value prevents pinch zoom
+func disablesZoom(viewport string) bool {
+	for _, part := range strings.FieldsFunc(strings.ToLower(viewport), func(r rune) bool { return r == ',' || r == ';' }) {
+		key, value, _ := strings.Cut(part, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch key {
+		case "user-scalable":
+			if value == "no" || value == "0" {
+				return true
+			}
+		case "maximum-scale":
+			if scale, err := strconv.ParseFloat(value, 64); err == nil && scale < 2 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitDirectives splits a comma-separated list, optionally lower-casing each entry
+func splitDirectives(value string, lower bool) []string {
+	var directives []string
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if lower {
+			part = strings.ToLower(part)
+		}
+		if part != "" {
+			directives = append(directives, part)
+		}
+	}
+	return directives
+}
+
+// setOnce sets target to value unless it already holds a value
+func setOnce(target *string, value string) {
+	if *target == "" {
+		*target = value
+	}
+}
+
+// setProperty records the first value of a social property, allocating the map when needed
+func setProperty(properties map[string]string, key, value string) map[string]string {
+	if properties == nil {
+		properties = make(map[string]string)
+	}
+	if _, ok := properties[key]; !ok {
+		properties[key] = value
+	}
+	return properties
+}
+
+// resolveHref resolves a link target against the document base URL
+func resolveHref(base *url.URL, href string) (string, bool) {
+	href = strings.TrimSpace(href)
+	if href == "" {
+		return "", false
+	}
+	parsed, err := url.Parse(href)
+	if err != nil {
+		return "", false
+	}
+	return base.ResolveReference(parsed).String(), true
+}
+
+// hasNoindex reports whether robots directives keep the page out of search indexes.
+// X-Robots-Tag directives may be scoped to a crawler, as in "googlebot: noindex".
+func hasNoindex(directives []string) bool {
+	for _, directive := range directives {
+		if _, scoped, ok := strings.Cut(directive, ":"); ok {
+			directive = strings.TrimSpace(scoped)
+		}
+		if directive == "noindex" || directive == "none" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasIconRel reports whether a link's rel tokens declare an icon
+func hasIconRel(rels []string) bool {
+	for _, rel := range rels {
+		if rel == "icon" || strings.HasSuffix(rel, "-icon") || strings.HasPrefix(rel, "apple-touch-icon") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractMetadata(t *testing.T) {
+	page := `<!DOCTYPE html><html lang="en"><head>
+		<meta charset="utf-8">
+		<meta name="viewport" content="width=device-width, initial-scale=1">
+		<meta name="description" content="A page about testing.">
+		<meta name="keywords" content="go, testing , ,html">
+		<meta name="robots" content="index, FOLLOW">
+		<link rel="canonical" href="/articles/testing">
+		<link rel="icon" href="/favicon.ico" sizes="32x32">
+		<link rel="apple-touch-icon" href="https://cdn.example.com/touch.png">
+		<meta property="og:title" content="Testing">
+		<meta property="og:type" content="article">
+		<meta property="og:image" content="https://example.com/og.png">
+		<meta property="og:image" content="https://example.com/other.png">
+		<meta property="og:url" content="https://example.com/articles/testing">
+		<meta name="twitter:card" content="summary_large_image">
+	</head><body></body></html>`
+
+	metadata := ExtractMetadata(mustParse(t, page), "https://example.com/articles/testing?ref=feed", nil)
+
+	assert.Equal(t, Metadata{
+		Description: "A page about testing.",
+		Keywords:    []string{"go", "testing", "html"},
+		Viewport:    "width=device-width, initial-scale=1",
+		Charset:     "utf-8",
+		Canonical:   "https://example.com/articles/testing",
+		Robots:      []string{"index", "follow"},
+		Lang:        "en",
+		OpenGraph: map[string]string{
+			"og:title": "Testing",
+			"og:type":  "article",
+			"og:image": "https://example.com/og.png",
+			"og:url":   "https://example.com/articles/testing",
+		},
+		Twitter: map[string]string{"twitter:card": "summary_large_image"},
+		Icons: []Icon{
+			{Rel: "icon", Href: "https://example.com/favicon.ico", Sizes: "32x32"},
+			{Rel: "apple-touch-icon", Href: "https://cdn.example.com/touch.png"},
+		},
+	}, metadata)
+}
+
+func TestExtractMetadata_MissingEverything(t *testing.T) {
+	metadata := ExtractMetadata(mustParse(t, `<html><head><title>Bare</title></head></html>`), "https://example.com/", nil)
+
+	assert.Equal(t, []string{
+		RuleMetaDescriptionMissing,
+		RuleMetaViewportMissing,
+		RuleMetaCharsetMissing,
+		RuleCanonicalMissing,
+		RuleHTMLLangMissing,
+		RuleOpenGraphIncomplete,
+		RuleTwitterCardMissing,
+		RuleIconMissing,
+	}, findingRules(metadata.Findings))
+}
+
+func TestExtractMetadata_Findings(t *testing.T) {
+	page := `<html lang="en"><head>
+		<meta charset="utf-8">
+		<meta http-equiv="Content-Type" content="text/html; charset=iso-8859-1">
+		<meta name="viewport" content="width=device-width, user-scalable=no">
+		<meta name="description" content="` + strings.Repeat("long ", 40) + `">
+		<meta name="description" content="Second">
+		<link rel="canonical" href="https://example.com/a">
+		<link rel="canonical" href="https://example.com/b">
+		<link rel="shortcut icon" href="/favicon.ico">
+		<meta property="og:title" content="Only a title">
+		<meta name="twitter:card" content="summary">
+	</head></html>`
+
+	metadata := ExtractMetadata(mustParse(t, page), "https://example.com/", nil)
+
+	assert.Equal(t, []string{
+		RuleMetaDescriptionTooLong,
+		RuleMetaDescriptionDuplicate,
+		RuleMetaViewportNoZoom,
+		RuleMetaCharsetDuplicate,
+		RuleCanonicalDuplicate,
+		RuleOpenGraphIncomplete,
+	}, findingRules(metadata.Findings))
+	assert.Equal(t, "Open Graph is missing og:type, og:image, og:url", metadata.Findings[5].Message)
+	assert.Equal(t, "html > head > meta:nth-of-type(5)", metadata.Findings[1].Path)
+}
+
+func TestExtractMetadata_ResponseHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("Content-Type", "text/html; charset=windows-1252")
+	header.Add("X-Robots-Tag", "googlebot: noindex, nofollow")
+
+	metadata := ExtractMetadata(mustParse(t, `<html lang="en"></html>`), "https://example.com/", header)
+
+	assert.Equal(t, "windows-1252", metadata.Charset, "The header charset counts when no meta tag declares one")
+	assert.Equal(t, []string{"googlebot: noindex", "nofollow"}, metadata.XRobotsTag)
+	assert.Contains(t, findingRules(metadata.Findings), RuleRobotsNoindex)
+	assert.NotContains(t, findingRules(metadata.Findings), RuleMetaCharsetMissing)
+}
+
+func TestExtractMetadata_TwitterNameAndProperty(t *testing.T) {
+	page := `<head>
+		<meta name="twitter:card" property="card" content="summary">
+		<meta name="author" property="twitter:creator" content="@example">
+		<meta property="twitter:site" content="@site">
+	</head>`
+
+	metadata := ExtractMetadata(mustParse(t, page), "https://example.com/", nil)
+
+	assert.Equal(t, map[string]string{
+		"twitter:card":    "summary",
+		"twitter:creator": "@example",
+		"twitter:site":    "@site",
+	}, metadata.Twitter, "The twitter: attribute is the key when a tag sets both name and property")
+}
+
+func TestExtractMetadata_ResolvesAgainstBaseHref(t *testing.T) {
+	page := `<head>
+		<base href="https://static.example.com/">
+		<link rel="canonical" href="/post">
+		<link rel="icon" href="favicon.ico">
+	</head>`
+
+	metadata := ExtractMetadata(mustParse(t, page), "https://example.com/blog/post", nil)
+
+	assert.Equal(t, "https://static.example.com/post", metadata.Canonical)
+	assert.Equal(t, "https://static.example.com/favicon.ico", metadata.Icons[0].Href)
+}
+
+func TestDisablesZoom(t *testing.T) {
+	assert.True(t, disablesZoom("width=device-width, user-scalable=no"))
+	assert.True(t, disablesZoom("width=device-width, maximum-scale=1.0"))
+	assert.False(t, disablesZoom("width=device-width, initial-scale=1"))
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/uikee/web-analyzer-service/internal/fetcher"
+)
+
+// Rules reported for the redirect chain
+const (
+	RuleRedirectChainLong   = "redirect-chain-long"
+	RuleRedirectLoop        = "redirect-loop"
+	RuleRedirectDowngrade   = "redirect-https-to-http"
+	RuleRedirectMixedStatus = "redirect-mixed-status"
+)
+
+// maxRedirectHops is the number of redirects above which a chain is reported as long
+const maxRedirectHops = 3
+
+// RedirectHop is a single request in the redirect chain
+type RedirectHop struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code"`
+	Location   string `json:"location,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// RedirectChain describes how the requested URL led to the page that was analyzed
+type RedirectChain struct {
+	// Hops lists every request in order; the last one served the page, unless the
+	// chain was given up on, in which case FinalURL is empty
+	Hops      []RedirectHop `json:"hops"`
+	FinalURL  string        `json:"final_url"`
+	Redirects int           `json:"redirects"`
+	Findings  []Finding     `json:"findings,omitempty"`
+}
+
+// AnalyzeRedirects describes the hops a fetch went through and reports long
+// chains, revisited URLs, HTTPS to HTTP downgrades and mixed permanent and
+// temporary redirects
+func AnalyzeRedirects(hops []fetcher.Hop) RedirectChain {
+	chain := RedirectChain{Hops: make([]RedirectHop, 0, len(hops))}
+	for _, hop := range hops {
+		chain.Hops = append(chain.Hops, RedirectHop{
+			URL:        hop.URL,
+			StatusCode: hop.StatusCode,
+			Location:   hop.Location,
+			DurationMs: hop.Duration.Milliseconds(),
+		})
+	}
+	if len(hops) == 0 {
+		return chain
+	}
+	// A chain given up on ends with a redirect that was not followed
+	if last := hops[len(hops)-1]; last.Location != "" {
+		chain.Redirects = len(hops)
+	} else {
+		chain.FinalURL = last.URL
+		chain.Redirects = len(hops) - 1
+	}
+
+	if chain.Redirects > maxRedirectHops {
+		chain.Findings = append(chain.Findings, Finding{
+			Rule:     RuleRedirectChainLong,
+			Severity: SeverityMedium,
+			Message:  fmt.Sprintf("The page is reached through %d redirects; at most %d are recommended", chain.Redirects, maxRedirectHops),
+		})
+	}
+
+	visits := make(map[string]int, len(hops))
+	var permanent, temporary bool
+	for i, hop := range hops {
+		// Every URL of a loop is reported once, however often the chain went round
+		if visits[hop.URL]++; visits[hop.URL] == 2 {
+			chain.Findings = append(chain.Findings, Finding{
+				Rule:     RuleRedirectLoop,
+				Severity: SeverityHigh,
+				Message:  fmt.Sprintf("The redirect chain visits %s more than once", hop.URL),
+			})
+		}
+
+		if i > 0 && isHTTPS(hops[i-1].URL) && !isHTTPS(hop.URL) {
+			chain.Findings = append(chain.Findings, Finding{
+				Rule:     RuleRedirectDowngrade,
+				Severity: SeverityHigh,
+				Message:  fmt.Sprintf("%s redirects from HTTPS to plain HTTP (%s)", hops[i-1].URL, hop.URL),
+			})
+		}
+
+		switch hop.StatusCode {
+		case http.StatusMovedPermanently, http.StatusPermanentRedirect:
+			permanent = true
+		case http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect:
+			temporary = true
+		}
+	}
+
+	if permanent && temporary {
+		chain.Findings = append(chain.Findings, Finding{
+			Rule:     RuleRedirectMixedStatus,
+			Severity: SeverityLow,
+			Message:  "The redirect chain mixes permanent (301/308) and temporary (302/303/307) redirects",
+		})
+	}
+	return chain
+}
+
+// isHTTPS reports whether rawURL uses the https scheme
+func isHTTPS(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	return err == nil && parsed.Scheme == "https"
+}
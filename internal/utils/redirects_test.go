@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uikee/web-analyzer-service/internal/fetcher"
+)
+
+func TestAnalyzeRedirects(t *testing.T) {
+	chain := AnalyzeRedirects([]fetcher.Hop{
+		{URL: "http://example.com/", StatusCode: 301, Location: "https://example.com/", Duration: 120 * time.Millisecond},
+		{URL: "https://example.com/", StatusCode: 200, Duration: 80 * time.Millisecond},
+	})
+
+	assert.Equal(t, RedirectChain{
+		Hops: []RedirectHop{
+			{URL: "http://example.com/", StatusCode: 301, Location: "https://example.com/", DurationMs: 120},
+			{URL: "https://example.com/", StatusCode: 200, DurationMs: 80},
+		},
+		FinalURL:  "https://example.com/",
+		Redirects: 1,
+	}, chain)
+}
+
+func TestAnalyzeRedirects_NoRedirects(t *testing.T) {
+	chain := AnalyzeRedirects([]fetcher.Hop{{URL: "https://example.com/", StatusCode: 200}})
+
+	assert.Equal(t, "https://example.com/", chain.FinalURL)
+	assert.Zero(t, chain.Redirects)
+	assert.Empty(t, chain.Findings)
+	assert.Empty(t, AnalyzeRedirects(nil).Hops)
+}
+
+func TestAnalyzeRedirects_Findings(t *testing.T) {
+	chain := AnalyzeRedirects([]fetcher.Hop{
+		{URL: "https://example.com/a", StatusCode: 301, Location: "/b"},
+		{URL: "https://example.com/b", StatusCode: 302, Location: "/a"},
+		{URL: "https://example.com/a", StatusCode: 307, Location: "http://example.com/c"},
+		{URL: "http://example.com/c", StatusCode: 308, Location: "/d"},
+		{URL: "http://example.com/d", StatusCode: 200},
+	})
+
+	assert.Equal(t, 4, chain.Redirects)
+	assert.Equal(t, []string{
+		RuleRedirectChainLong,
+		RuleRedirectLoop,
+		RuleRedirectDowngrade,
+		RuleRedirectMixedStatus,
+	}, findingRules(chain.Findings))
+}
+
+func TestAnalyzeRedirects_ConsistentPermanentRedirects(t *testing.T) {
+	chain := AnalyzeRedirects([]fetcher.Hop{
+		{URL: "http://example.com/", StatusCode: 301, Location: "https://example.com/"},
+		{URL: "https://example.com/", StatusCode: 308, Location: "https://www.example.com/"},
+		{URL: "https://www.example.com/", StatusCode: 200},
+	})
+
+	assert.Empty(t, chain.Findings, "An HTTP to HTTPS upgrade with permanent redirects is fine")
+}
+
+func TestAnalyzeRedirects_Loop(t *testing.T) {
+	chain := AnalyzeRedirects([]fetcher.Hop{
+		{URL: "https://example.com/a", StatusCode: 302, Location: "/b"},
+		{URL: "https://example.com/b", StatusCode: 302, Location: "/a"},
+		{URL: "https://example.com/a", StatusCode: 302, Location: "/b"},
+		{URL: "https://example.com/b", StatusCode: 302, Location: "/a"},
+		{URL: "https://example.com/a", StatusCode: 302, Location: "/b"},
+	})
+
+	assert.Empty(t, chain.FinalURL, "A loop never reaches a page")
+	assert.Equal(t, 5, chain.Redirects)
+	assert.Equal(t, []string{RuleRedirectChainLong, RuleRedirectLoop, RuleRedirectLoop}, findingRules(chain.Findings))
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ResponseInfo summarizes the HTTP response the page was served with
+type ResponseInfo struct {
+	StatusCode  int    `json:"status_code"`
+	Protocol    string `json:"protocol"`
+	ContentType string `json:"content_type,omitempty"`
+	Charset     string `json:"charset,omitempty"`
+
+	// Size is the size of the decoded body in bytes
+	Size        int         `json:"size"`
+	Compression string      `json:"compression,omitempty"`
+	Caching     CachingInfo `json:"caching"`
+}
+
+// CachingInfo holds the caching headers of a response
+type CachingInfo struct {
+	CacheControl string `json:"cache_control,omitempty"`
+	Expires      string `json:"expires,omitempty"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Age          string `json:"age,omitempty"`
+	Vary         string `json:"vary,omitempty"`
+
+	// Cacheable is false when Cache-Control forbids storing the response
+	Cacheable bool `json:"cacheable"`
+}
+
+// DescribeResponse summarizes status, protocol, content type, size, compression and caching headers
+func DescribeResponse(statusCode int, proto string, header http.Header, size int, compression string) ResponseInfo {
+	if header == nil {
+		header = http.Header{}
+	}
+
+	info := ResponseInfo{
+		StatusCode:  statusCode,
+		Protocol:    proto,
+		Size:        size,
+		Compression: compression,
+		Caching: CachingInfo{
+			CacheControl: header.Get("Cache-Control"),
+			Expires:      header.Get("Expires"),
+			ETag:         header.Get("ETag"),
+			LastModified: header.Get("Last-Modified"),
+			Age:          header.Get("Age"),
+			Vary:         strings.Join(header.Values("Vary"), ", "),
+		},
+	}
+
+	if contentType := header.Get("Content-Type"); contentType != "" {
+		if mediaType, params, err := mime.ParseMediaType(contentType); err == nil {
+			info.ContentType = mediaType
+			info.Charset = params["charset"]
+		} else {
+			info.ContentType = contentType
+		}
+	}
+
+	_, noStore := parseDirectives(info.Caching.CacheControl, ",")["no-store"]
+	info.Caching.Cacheable = !noStore
+
+	return info
+}
+
+// parseDirectives splits a header value such as "max-age=60, no-cache" into
+// lower-cased directive names and their unquoted values
+func parseDirectives(value, separator string) map[string]string {
+	directives := map[string]string{}
+	for _, part := range strings.Split(value, separator) {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" {
+			directives[name] = strings.Trim(strings.TrimSpace(arg), `"`)
+		}
+	}
+	return directives
+}
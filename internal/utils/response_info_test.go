@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribeResponse(t *testing.T) {
+	header := http.Header{}
+	header.Set("Content-Type", "text/html; charset=UTF-8")
+	header.Set("Cache-Control", "public, max-age=600")
+	header.Set("ETag", `"abc"`)
+	header.Set("Last-Modified", "Wed, 21 Oct 2015 07:28:00 GMT")
+	header.Add("Vary", "Accept-Encoding")
+	header.Add("Vary", "Cookie")
+
+	info := DescribeResponse(200, "HTTP/2.0", header, 1234, "gzip")
+
+	assert.Equal(t, ResponseInfo{
+		StatusCode:  200,
+		Protocol:    "HTTP/2.0",
+		ContentType: "text/html",
+		Charset:     "UTF-8",
+		Size:        1234,
+		Compression: "gzip",
+		Caching: CachingInfo{
+			CacheControl: "public, max-age=600",
+			ETag:         `"abc"`,
+			LastModified: "Wed, 21 Oct 2015 07:28:00 GMT",
+			Vary:         "Accept-Encoding, Cookie",
+			Cacheable:    true,
+		},
+	}, info)
+}
+
+func TestDescribeResponse_NoStore(t *testing.T) {
+	header := http.Header{"Cache-Control": []string{"private, No-Store"}}
+
+	info := DescribeResponse(200, "HTTP/1.1", header, 0, "")
+
+	assert.False(t, info.Caching.Cacheable)
+	assert.Empty(t, info.ContentType)
+}
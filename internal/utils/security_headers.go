@@ -0,0 +1,242 @@
+package utils
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Header audit verdicts
+const (
+	VerdictPass = "pass"
+	VerdictWarn = "warn"
+	VerdictFail = "fail"
+)
+
+// minHSTSMaxAge is the shortest HSTS lifetime considered adequate (180 days)
+const minHSTSMaxAge = 180 * 24 * 60 * 60
+
+// HeaderVerdict is the audit result for a single security header
+type HeaderVerdict struct {
+	Header  string `json:"header"`
+	Value   string `json:"value,omitempty"`
+	Verdict string `json:"verdict"`
+	Message string `json:"message,omitempty"`
+}
+
+// SecurityHeaders is the security-header audit of a response
+type SecurityHeaders struct {
+	Headers []HeaderVerdict `json:"headers"`
+
+	// CSP maps each Content-Security-Policy directive to its sources
+	CSP map[string][]string `json:"csp,omitempty"`
+}
+
+// AuditSecurityHeaders gives a pass/warn/fail verdict for HSTS, CSP, X-Frame-Options,
+// X-Content-Type-Options, Referrer-Policy, Permissions-Policy, COOP and COEP.
+// https tells whether the page was served over TLS, which HSTS depends on.
+func AuditSecurityHeaders(header http.Header, https bool) SecurityHeaders {
+	if header == nil {
+		header = http.Header{}
+	}
+
+	audit := SecurityHeaders{CSP: ParseCSP(header.Get("Content-Security-Policy"))}
+	audit.Headers = []HeaderVerdict{
+		auditHSTS(header.Get("Strict-Transport-Security"), https),
+		auditCSP(header, audit.CSP),
+		auditFrameOptions(header.Get("X-Frame-Options"), audit.CSP),
+		auditContentTypeOptions(header.Get("X-Content-Type-Options")),
+		auditReferrerPolicy(header.Get("Referrer-Policy")),
+		auditPermissionsPolicy(header),
+		auditCrossOriginPolicy("Cross-Origin-Opener-Policy", header.Get("Cross-Origin-Opener-Policy"),
+			[]string{"same-origin", "same-origin-allow-popups", "noopener-allow-popups"}),
+		auditCrossOriginPolicy("Cross-Origin-Embedder-Policy", header.Get("Cross-Origin-Embedder-Policy"),
+			[]string{"require-corp", "credentialless"}),
+	}
+	return audit
+}
+
+// ParseCSP parses a Content-Security-Policy value into directives and their sources.
+// Only the first occurrence of a directive counts, as in browsers.
+func ParseCSP(policy string) map[string][]string {
+	if strings.TrimSpace(policy) == "" {
+		return nil
+	}
+
+	directives := map[string][]string{}
+	for _, part := range strings.Split(policy, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.ToLower(fields[0])
+		if _, seen := directives[name]; !seen {
+			directives[name] = append([]string{}, fields[1:]...)
+		}
+	}
+	return directives
+}
+
+// verdict builds the HeaderVerdict of a single header
+func verdict(header, value, result, message string) HeaderVerdict {
+	return HeaderVerdict{Header: header, Value: value, Verdict: result, Message: message}
+}
+
+// auditHSTS checks that HSTS is set with a long max-age and includeSubDomains
+func auditHSTS(value string, https bool) HeaderVerdict {
+	const name = "Strict-Transport-Security"
+	if !https {
+		return verdict(name, value, VerdictWarn, "Page is served over plain HTTP, so HSTS cannot protect it")
+	}
+	if value == "" {
+		return verdict(name, value, VerdictFail, "Header is missing")
+	}
+
+	directives := parseDirectives(value, ";")
+	maxAge, err := strconv.Atoi(directives["max-age"])
+	switch {
+	case err != nil:
+		return verdict(name, value, VerdictFail, "max-age is missing or invalid")
+	case maxAge == 0:
+		return verdict(name, value, VerdictFail, "max-age=0 disables HSTS")
+	case maxAge < minHSTSMaxAge:
+		return verdict(name, value, VerdictWarn, "max-age is shorter than 180 days")
+	}
+	if _, ok := directives["includesubdomains"]; !ok {
+		return verdict(name, value, VerdictWarn, "includeSubDomains is not set")
+	}
+	return verdict(name, value, VerdictPass, "HSTS is enabled")
+}
+
+// auditCSP checks that the enforced policy restricts script and object sources
+func auditCSP(header http.Header, csp map[string][]string) HeaderVerdict {
+	const name = "Content-Security-Policy"
+	value := header.Get(name)
+	if csp == nil {
+		if header.Get("Content-Security-Policy-Report-Only") != "" {
+			return verdict(name, value, VerdictWarn, "Policy is only reported, not enforced")
+		}
+		return verdict(name, value, VerdictFail, "Header is missing")
+	}
+
+	scripts, ok := csp["script-src"]
+	if !ok {
+		scripts, ok = csp["default-src"]
+	}
+	if !ok {
+		return verdict(name, value, VerdictWarn, "Neither script-src nor default-src restricts scripts")
+	}
+
+	hasNonceOrHash := false
+	for _, source := range scripts {
+		lower := strings.ToLower(source)
+		if strings.HasPrefix(lower, "'nonce-") || strings.HasPrefix(lower, "'sha") {
+			hasNonceOrHash = true
+		}
+	}
+	for _, source := range scripts {
+		switch strings.ToLower(source) {
+		case "'unsafe-inline'":
+			// Browsers ignore 'unsafe-inline' when a nonce or hash is present
+			if !hasNonceOrHash {
+				return verdict(name, value, VerdictWarn, "Scripts allow 'unsafe-inline'")
+			}
+		case "'unsafe-eval'":
+			return verdict(name, value, VerdictWarn, "Scripts allow 'unsafe-eval'")
+		case "*", "http:", "https:", "data:":
+			return verdict(name, value, VerdictWarn, "Scripts may load from any origin ("+source+")")
+		}
+	}
+
+	if _, ok := csp["object-src"]; !ok {
+		if _, ok := csp["default-src"]; !ok {
+			return verdict(name, value, VerdictWarn, "object-src is not restricted")
+		}
+	}
+	return verdict(name, value, VerdictPass, "Policy restricts script sources")
+}
+
+// auditFrameOptions checks that framing is restricted by X-Frame-Options or CSP frame-ancestors
+func auditFrameOptions(value string, csp map[string][]string) HeaderVerdict {
+	const name = "X-Frame-Options"
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case "DENY", "SAMEORIGIN":
+		return verdict(name, value, VerdictPass, "Framing is restricted")
+	case "":
+		if _, ok := csp["frame-ancestors"]; ok {
+			return verdict(name, value, VerdictPass, "Framing is restricted by CSP frame-ancestors")
+		}
+		return verdict(name, value, VerdictFail, "Header is missing and CSP sets no frame-ancestors")
+	default:
+		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(value)), "ALLOW-FROM") {
+			return verdict(name, value, VerdictWarn, "ALLOW-FROM is obsolete; use CSP frame-ancestors")
+		}
+		return verdict(name, value, VerdictFail, "Value is not DENY or SAMEORIGIN")
+	}
+}
+
+// auditContentTypeOptions checks that MIME sniffing is disabled
+func auditContentTypeOptions(value string) HeaderVerdict {
+	const name = "X-Content-Type-Options"
+	switch {
+	case value == "":
+		return verdict(name, value, VerdictFail, "Header is missing")
+	case strings.EqualFold(strings.TrimSpace(value), "nosniff"):
+		return verdict(name, value, VerdictPass, "MIME sniffing is disabled")
+	default:
+		return verdict(name, value, VerdictFail, "Value must be nosniff")
+	}
+}
+
+// auditReferrerPolicy grades the effective referrer policy by how much of the URL it leaks
+func auditReferrerPolicy(value string) HeaderVerdict {
+	const name = "Referrer-Policy"
+	if strings.TrimSpace(value) == "" {
+		return verdict(name, value, VerdictWarn, "Header is missing; browsers fall back to strict-origin-when-cross-origin")
+	}
+
+	// The last policy the browser understands wins
+	policies := strings.Split(value, ",")
+	policy := strings.ToLower(strings.TrimSpace(policies[len(policies)-1]))
+	switch policy {
+	case "no-referrer", "same-origin", "strict-origin", "strict-origin-when-cross-origin":
+		return verdict(name, value, VerdictPass, "Referrer is not leaked to other origins over insecure connections")
+	case "origin", "origin-when-cross-origin", "no-referrer-when-downgrade":
+		return verdict(name, value, VerdictWarn, "Policy "+policy+" shares more of the URL than necessary")
+	case "unsafe-url":
+		return verdict(name, value, VerdictFail, "unsafe-url sends the full URL to every origin")
+	default:
+		return verdict(name, value, VerdictWarn, "Unknown policy "+policy)
+	}
+}
+
+// auditPermissionsPolicy checks that a Permissions-Policy restricts browser features
+func auditPermissionsPolicy(header http.Header) HeaderVerdict {
+	const name = "Permissions-Policy"
+	value := header.Get(name)
+	switch {
+	case value != "":
+		return verdict(name, value, VerdictPass, "Browser features are restricted")
+	case header.Get("Feature-Policy") != "":
+		return verdict(name, value, VerdictWarn, "Only the deprecated Feature-Policy header is set")
+	default:
+		return verdict(name, value, VerdictWarn, "Header is missing")
+	}
+}
+
+// auditCrossOriginPolicy checks a COOP or COEP header against the values in secure
+func auditCrossOriginPolicy(name, value string, secure []string) HeaderVerdict {
+	policy := strings.ToLower(strings.TrimSpace(value))
+	if policy == "" {
+		return verdict(name, value, VerdictWarn, "Header is missing")
+	}
+	for _, accepted := range secure {
+		if policy == accepted {
+			return verdict(name, value, VerdictPass, "Cross-origin isolation policy is "+policy)
+		}
+	}
+	if policy == "unsafe-none" {
+		return verdict(name, value, VerdictWarn, "unsafe-none provides no isolation")
+	}
+	return verdict(name, value, VerdictFail, "Unknown policy "+policy)
+}
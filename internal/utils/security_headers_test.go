@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// verdicts maps each audited header to its verdict
+func verdicts(audit SecurityHeaders) map[string]string {
+	result := map[string]string{}
+	for _, header := range audit.Headers {
+		result[header.Header] = header.Verdict
+	}
+	return result
+}
+
+func TestAuditSecurityHeaders_Hardened(t *testing.T) {
+	header := http.Header{}
+	header.Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains; preload")
+	header.Set("Content-Security-Policy", "default-src 'self'; script-src 'self' 'nonce-abc' 'unsafe-inline'; frame-ancestors 'none'")
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Referrer-Policy", "no-referrer, strict-origin-when-cross-origin")
+	header.Set("Permissions-Policy", "geolocation=()")
+	header.Set("Cross-Origin-Opener-Policy", "same-origin")
+	header.Set("Cross-Origin-Embedder-Policy", "require-corp")
+
+	audit := AuditSecurityHeaders(header, true)
+
+	assert.Equal(t, map[string]string{
+		"Strict-Transport-Security":    VerdictPass,
+		"Content-Security-Policy":      VerdictPass,
+		"X-Frame-Options":              VerdictPass,
+		"X-Content-Type-Options":       VerdictPass,
+		"Referrer-Policy":              VerdictPass,
+		"Permissions-Policy":           VerdictPass,
+		"Cross-Origin-Opener-Policy":   VerdictPass,
+		"Cross-Origin-Embedder-Policy": VerdictPass,
+	}, verdicts(audit))
+	assert.Equal(t, []string{"'self'", "'nonce-abc'", "'unsafe-inline'"}, audit.CSP["script-src"])
+	assert.Equal(t, []string{"'none'"}, audit.CSP["frame-ancestors"])
+}
+
+func TestAuditSecurityHeaders_Missing(t *testing.T) {
+	audit := AuditSecurityHeaders(http.Header{}, true)
+
+	assert.Equal(t, map[string]string{
+		"Strict-Transport-Security":    VerdictFail,
+		"Content-Security-Policy":      VerdictFail,
+		"X-Frame-Options":              VerdictFail,
+		"X-Content-Type-Options":       VerdictFail,
+		"Referrer-Policy":              VerdictWarn,
+		"Permissions-Policy":           VerdictWarn,
+		"Cross-Origin-Opener-Policy":   VerdictWarn,
+		"Cross-Origin-Embedder-Policy": VerdictWarn,
+	}, verdicts(audit))
+	assert.Nil(t, audit.CSP)
+}
+
+func TestAuditSecurityHeaders_Weak(t *testing.T) {
+	header := http.Header{}
+	header.Set("Strict-Transport-Security", "max-age=3600")
+	header.Set("Content-Security-Policy", "script-src 'self' 'unsafe-eval'")
+	header.Set("X-Frame-Options", "ALLOW-FROM https://example.com")
+	header.Set("X-Content-Type-Options", "sniff")
+	header.Set("Referrer-Policy", "unsafe-url")
+	header.Set("Feature-Policy", "camera 'none'")
+	header.Set("Cross-Origin-Opener-Policy", "unsafe-none")
+
+	audit := AuditSecurityHeaders(header, true)
+
+	assert.Equal(t, map[string]string{
+		"Strict-Transport-Security":    VerdictWarn,
+		"Content-Security-Policy":      VerdictWarn,
+		"X-Frame-Options":              VerdictWarn,
+		"X-Content-Type-Options":       VerdictFail,
+		"Referrer-Policy":              VerdictFail,
+		"Permissions-Policy":           VerdictWarn,
+		"Cross-Origin-Opener-Policy":   VerdictWarn,
+		"Cross-Origin-Embedder-Policy": VerdictWarn,
+	}, verdicts(audit))
+}
+
+func TestAuditSecurityHeaders_HSTSOverHTTP(t *testing.T) {
+	audit := AuditSecurityHeaders(http.Header{}, false)
+
+	assert.Equal(t, VerdictWarn, verdicts(audit)["Strict-Transport-Security"])
+}
+
+func TestParseCSP(t *testing.T) {
+	csp := ParseCSP("default-src 'self';; IMG-SRC * data: ; default-src 'none'; upgrade-insecure-requests")
+
+	assert.Equal(t, map[string][]string{
+		"default-src":               {"'self'"},
+		"img-src":                   {"*", "data:"},
+		"upgrade-insecure-requests": {},
+	}, csp)
+}
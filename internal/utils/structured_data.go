@@ -0,0 +1,351 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Structured data formats
+const (
+	StructuredDataJSONLD    = "json-ld"
+	StructuredDataMicrodata = "microdata"
+	StructuredDataRDFa      = "rdfa"
+)
+
+// Rules reported for structured data
+const (
+	RuleStructuredDataInvalidJSON = "structured-data-invalid-json"
+	RuleStructuredDataMissingType = "structured-data-missing-type"
+)
+
+// schemaOrgPrefixes are stripped from types so that all formats report e.g. "Product"
+var schemaOrgPrefixes = []string{"https://schema.org/", "http://schema.org/", "schema:"}
+
+// StructuredEntity is a single item described by JSON-LD, Microdata or RDFa
+type StructuredEntity struct {
+	Format string   `json:"format"`
+	Types  []string `json:"types"`
+	ID     string   `json:"id,omitempty"`
+
+	// Properties maps property names to their values: strings, numbers, booleans
+	// or nested entities
+	Properties map[string][]any `json:"properties"`
+	Path       string           `json:"path,omitempty"`
+}
+
+// StructuredData lists the entities a page exposes and the distinct types among them
+type StructuredData struct {
+	Entities []StructuredEntity `json:"entities"`
+	Types    []string           `json:"types"`
+	Findings []Finding          `json:"findings,omitempty"`
+}
+
+// ExtractStructuredData parses JSON-LD blocks, Microdata and basic RDFa into a
+// normalized list of entities. Invalid JSON-LD is reported as a finding.
+func ExtractStructuredData(doc *Document) StructuredData {
+	data := StructuredData{Entities: []StructuredEntity{}}
+
+	doc.Walk(func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+
+		if n.Data == "script" {
+			if scriptType, _ := attr(n, "type"); isJSONLD(scriptType) {
+				entities, finding := parseJSONLD(n)
+				data.Entities = append(data.Entities, entities...)
+				if finding != nil {
+					data.Findings = append(data.Findings, *finding)
+				}
+			}
+			return false
+		}
+
+		// Top-level items only; nested ones are parsed as property values
+		if _, ok := attr(n, "itemscope"); ok {
+			if _, isProperty := attr(n, "itemprop"); !isProperty {
+				data.Entities = append(data.Entities, parseMicrodataItem(n))
+			}
+		}
+		if _, ok := attr(n, "typeof"); ok {
+			if _, isProperty := attr(n, "property"); !isProperty {
+				data.Entities = append(data.Entities, parseRDFaEntity(n, inheritedVocab(n)))
+				return false
+			}
+		}
+		return true
+	})
+
+	types := map[string]bool{}
+	for _, entity := range data.Entities {
+		collectTypes(entity, types)
+		if len(entity.Types) == 0 {
+			data.Findings = append(data.Findings, Finding{
+				Rule:     RuleStructuredDataMissingType,
+				Severity: SeverityLow,
+				Message:  fmt.Sprintf("A %s entity has no type", entity.Format),
+				Path:     entity.Path,
+			})
+		}
+	}
+
+	data.Types = make([]string, 0, len(types))
+	for t := range types {
+		data.Types = append(data.Types, t)
+	}
+	sort.Strings(data.Types)
+
+	return data
+}
+
+// collectTypes adds the types of an entity and its nested entities to types
+func collectTypes(entity StructuredEntity, types map[string]bool) {
+	for _, t := range entity.Types {
+		types[t] = true
+	}
+	for _, values := range entity.Properties {
+		for _, value := range values {
+			if nested, ok := value.(StructuredEntity); ok {
+				collectTypes(nested, types)
+			}
+		}
+	}
+}
+
+// normalizeType shortens schema.org type IRIs to their local name
+func normalizeType(t string) string {
+	for _, prefix := range schemaOrgPrefixes {
+		if strings.HasPrefix(t, prefix) {
+			return strings.TrimPrefix(t, prefix)
+		}
+	}
+	return t
+}
+
+// isJSONLD reports whether a script type is JSON-LD
+func isJSONLD(scriptType string) bool {
+	mediaType, _, _ := strings.Cut(scriptType, ";")
+	return strings.EqualFold(strings.TrimSpace(mediaType), "application/ld+json")
+}
+
+// parseJSONLD parses a JSON-LD script into entities, returning a finding if the JSON is invalid
+func parseJSONLD(script *html.Node) ([]StructuredEntity, *Finding) {
+	var sb strings.Builder
+	for c := script.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(c.Data)
+	}
+	path := CSSPath(script)
+
+	var document any
+	if err := json.Unmarshal([]byte(strings.TrimSpace(sb.String())), &document); err != nil {
+		return nil, &Finding{
+			Rule:     RuleStructuredDataInvalidJSON,
+			Severity: SeverityHigh,
+			Message:  "JSON-LD block is not valid JSON: " + err.Error(),
+			Path:     path,
+		}
+	}
+
+	var entities []StructuredEntity
+	for _, node := range jsonLDNodes(document) {
+		entity := jsonLDEntity(node)
+		entity.Path = path
+		entities = append(entities, entity)
+	}
+	return entities, nil
+}
+
+// jsonLDNodes returns the top-level node objects of a JSON-LD document, unwrapping arrays and @graph
+func jsonLDNodes(document any) []map[string]any {
+	switch value := document.(type) {
+	case []any:
+		var nodes []map[string]any
+		for _, item := range value {
+			nodes = append(nodes, jsonLDNodes(item)...)
+		}
+		return nodes
+	case map[string]any:
+		if graph, ok := value["@graph"]; ok {
+			return jsonLDNodes(graph)
+		}
+		return []map[string]any{value}
+	default:
+		return nil
+	}
+}
+
+// jsonLDEntity converts a JSON-LD node object into an entity
+func jsonLDEntity(node map[string]any) StructuredEntity {
+	entity := StructuredEntity{Format: StructuredDataJSONLD, Types: []string{}, Properties: map[string][]any{}}
+
+	for key, value := range node {
+		switch key {
+		case "@context":
+		case "@type":
+			for _, t := range jsonLDValues(value) {
+				if s, ok := t.(string); ok {
+					entity.Types = append(entity.Types, normalizeType(s))
+				}
+			}
+		case "@id":
+			entity.ID, _ = value.(string)
+		default:
+			entity.Properties[key] = jsonLDValues(value)
+		}
+	}
+
+	sort.Strings(entity.Types)
+	return entity
+}
+
+// jsonLDValues flattens a JSON-LD value into a list, converting objects into nested entities
+func jsonLDValues(value any) []any {
+	switch v := value.(type) {
+	case []any:
+		var values []any
+		for _, item := range v {
+			values = append(values, jsonLDValues(item)...)
+		}
+		return values
+	case map[string]any:
+		// Value objects such as {"@value": "..."} carry a plain value
+		if literal, ok := v["@value"]; ok {
+			return []any{literal}
+		}
+		return []any{jsonLDEntity(v)}
+	default:
+		return []any{v}
+	}
+}
+
+// parseMicrodataItem converts an itemscope element into an entity
+func parseMicrodataItem(item *html.Node) StructuredEntity {
+	entity := StructuredEntity{Format: StructuredDataMicrodata, Types: []string{}, Properties: map[string][]any{}, Path: CSSPath(item)}
+	if itemType, ok := attr(item, "itemtype"); ok {
+		for _, t := range strings.Fields(itemType) {
+			entity.Types = append(entity.Types, normalizeType(t))
+		}
+	}
+	entity.ID, _ = attr(item, "itemid")
+
+	for c := item.FirstChild; c != nil; c = c.NextSibling {
+		walkNode(c, func(n *html.Node) bool {
+			if n.Type != html.ElementNode {
+				return true
+			}
+			_, scoped := attr(n, "itemscope")
+			if names, ok := attr(n, "itemprop"); ok {
+				var value any
+				if scoped {
+					value = parseMicrodataItem(n)
+				} else {
+					value = elementValue(n)
+				}
+				for _, name := range strings.Fields(names) {
+					entity.Properties[name] = append(entity.Properties[name], value)
+				}
+			}
+			// Properties inside a nested item belong to that item
+			return !scoped
+		})
+	}
+	return entity
+}
+
+// parseRDFaEntity converts a typeof element into an entity, using vocab to expand unprefixed types
+func parseRDFaEntity(node *html.Node, vocab string) StructuredEntity {
+	if v, ok := attr(node, "vocab"); ok {
+		vocab = strings.TrimSpace(v)
+	}
+
+	entity := StructuredEntity{Format: StructuredDataRDFa, Types: []string{}, Properties: map[string][]any{}, Path: CSSPath(node)}
+	typeOf, _ := attr(node, "typeof")
+	for _, t := range strings.Fields(typeOf) {
+		if !strings.Contains(t, ":") {
+			t = vocab + t
+		}
+		entity.Types = append(entity.Types, normalizeType(t))
+	}
+	entity.ID, _ = attr(node, "resource")
+	if entity.ID == "" {
+		entity.ID, _ = attr(node, "about")
+	}
+
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		walkNode(c, func(n *html.Node) bool {
+			if n.Type != html.ElementNode {
+				return true
+			}
+			_, typed := attr(n, "typeof")
+			if names, ok := attr(n, "property"); ok {
+				var value any
+				if typed {
+					value = parseRDFaEntity(n, vocab)
+				} else {
+					value = rdfaValue(n)
+				}
+				for _, name := range strings.Fields(names) {
+					name = normalizeType(name)
+					entity.Properties[name] = append(entity.Properties[name], value)
+				}
+			}
+			return !typed
+		})
+	}
+	return entity
+}
+
+// inheritedVocab returns the RDFa vocabulary in effect for n
+func inheritedVocab(n *html.Node) string {
+	for ; n != nil; n = n.Parent {
+		if n.Type == html.ElementNode {
+			if vocab, ok := attr(n, "vocab"); ok {
+				return strings.TrimSpace(vocab)
+			}
+		}
+	}
+	return ""
+}
+
+// rdfaValue returns the value of an RDFa property element
+func rdfaValue(n *html.Node) string {
+	for _, key := range []string{"content", "resource", "href", "src"} {
+		if value, ok := attr(n, key); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return nodeText(n)
+}
+
+// elementValue returns the value of a Microdata property element as defined by the HTML standard
+func elementValue(n *html.Node) string {
+	var key string
+	switch n.Data {
+	case "meta":
+		key = "content"
+	case "audio", "embed", "iframe", "img", "source", "track", "video":
+		key = "src"
+	case "a", "area", "link":
+		key = "href"
+	case "object":
+		key = "data"
+	case "data", "meter":
+		key = "value"
+	case "time":
+		key = "datetime"
+	}
+
+	if key != "" {
+		if value, ok := attr(n, key); ok {
+			return strings.TrimSpace(value)
+		}
+		if n.Data != "time" {
+			return ""
+		}
+	}
+	return nodeText(n)
+}
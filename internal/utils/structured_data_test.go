@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractStructuredData_JSONLD(t *testing.T) {
+	page := `<head><script type="application/ld+json">
+	{
+		"@context": "https://schema.org",
+		"@graph": [
+			{"@type": "Organization", "@id": "#org", "name": "Example Corp"},
+			{
+				"@type": ["Product", "https://schema.org/Thing"],
+				"name": "Widget",
+				"offers": {"@type": "Offer", "price": 9.99, "priceCurrency": "EUR"},
+				"color": ["red", "blue"]
+			}
+		]
+	}
+	</script></head>`
+
+	data := ExtractStructuredData(mustParse(t, page))
+
+	assert.Len(t, data.Entities, 2)
+	assert.Equal(t, []string{"Offer", "Organization", "Product", "Thing"}, data.Types)
+	assert.Empty(t, data.Findings)
+
+	organization := data.Entities[0]
+	assert.Equal(t, StructuredDataJSONLD, organization.Format)
+	assert.Equal(t, []string{"Organization"}, organization.Types)
+	assert.Equal(t, "#org", organization.ID)
+	assert.Equal(t, []any{"Example Corp"}, organization.Properties["name"])
+	assert.Equal(t, "html > head > script", organization.Path)
+
+	product := data.Entities[1]
+	assert.Equal(t, []any{"red", "blue"}, product.Properties["color"])
+	offer := product.Properties["offers"][0].(StructuredEntity)
+	assert.Equal(t, []string{"Offer"}, offer.Types)
+	assert.Equal(t, []any{9.99}, offer.Properties["price"])
+}
+
+func TestExtractStructuredData_InvalidJSONLD(t *testing.T) {
+	page := `<script type="application/ld+json">{"@type": "Person", "name": }</script>`
+
+	data := ExtractStructuredData(mustParse(t, page))
+
+	assert.Empty(t, data.Entities)
+	assert.Len(t, data.Findings, 1)
+	assert.Equal(t, RuleStructuredDataInvalidJSON, data.Findings[0].Rule)
+	assert.Contains(t, data.Findings[0].Message, "invalid character")
+}
+
+func TestExtractStructuredData_Microdata(t *testing.T) {
+	page := `<div itemscope itemtype="https://schema.org/Product" itemid="urn:sku:42">
+		<h1 itemprop="name">Widget</h1>
+		<img itemprop="image" src="/widget.png">
+		<div itemprop="offers" itemscope itemtype="http://schema.org/Offer">
+			<meta itemprop="price" content="9.99">
+			<span itemprop="name">Nested name</span>
+		</div>
+		<time itemprop="releaseDate" datetime="2024-05-01">May 1</time>
+	</div>`
+
+	data := ExtractStructuredData(mustParse(t, page))
+
+	assert.Len(t, data.Entities, 1)
+	product := data.Entities[0]
+	assert.Equal(t, StructuredDataMicrodata, product.Format)
+	assert.Equal(t, []string{"Product"}, product.Types)
+	assert.Equal(t, "urn:sku:42", product.ID)
+	assert.Equal(t, []any{"Widget"}, product.Properties["name"], "Nested item properties belong to the nested item")
+	assert.Equal(t, []any{"/widget.png"}, product.Properties["image"])
+	assert.Equal(t, []any{"2024-05-01"}, product.Properties["releaseDate"])
+
+	offer := product.Properties["offers"][0].(StructuredEntity)
+	assert.Equal(t, []string{"Offer"}, offer.Types)
+	assert.Equal(t, []any{"9.99"}, offer.Properties["price"])
+	assert.Equal(t, []string{"Offer", "Product"}, data.Types)
+}
+
+func TestExtractStructuredData_RDFa(t *testing.T) {
+	page := `<body vocab="https://schema.org/">
+		<div typeof="Person">
+			<span property="name">Ada Lovelace</span>
+			<a property="url" href="https://example.com/ada">Profile</a>
+			<div property="address" typeof="PostalAddress">
+				<span property="addressLocality">London</span>
+			</div>
+		</div>
+		<p typeof="schema:Event"><span property="schema:name">Launch</span></p>
+	</body>`
+
+	data := ExtractStructuredData(mustParse(t, page))
+
+	assert.Len(t, data.Entities, 2)
+	person := data.Entities[0]
+	assert.Equal(t, StructuredDataRDFa, person.Format)
+	assert.Equal(t, []string{"Person"}, person.Types)
+	assert.Equal(t, []any{"Ada Lovelace"}, person.Properties["name"])
+	assert.Equal(t, []any{"https://example.com/ada"}, person.Properties["url"])
+	address := person.Properties["address"][0].(StructuredEntity)
+	assert.Equal(t, []any{"London"}, address.Properties["addressLocality"])
+
+	assert.Equal(t, []string{"Event"}, data.Entities[1].Types)
+	assert.Equal(t, []any{"Launch"}, data.Entities[1].Properties["name"])
+	assert.Equal(t, []string{"Event", "Person", "PostalAddress"}, data.Types)
+}
+
+func TestExtractStructuredData_MissingType(t *testing.T) {
+	data := ExtractStructuredData(mustParse(t, `<div itemscope><span itemprop="name">Untyped</span></div>`))
+
+	assert.Equal(t, []string{RuleStructuredDataMissingType}, findingRules(data.Findings))
+	assert.Empty(t, data.Types)
+}
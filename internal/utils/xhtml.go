@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Namespaces of XML documents that map onto the html.Node tree
+const (
+	xhtmlNamespace = "http://www.w3.org/1999/xhtml"
+	svgNamespace   = "http://www.w3.org/2000/svg"
+	mathNamespace  = "http://www.w3.org/1998/Math/MathML"
+	xlinkNamespace = "http://www.w3.org/1999/xlink"
+	xmlNamespace   = "http://www.w3.org/XML/1998/namespace"
+)
+
+// ErrMalformedXML indicates that an XHTML document is not well-formed XML
+var ErrMalformedXML = errors.New("malformed XML document")
+
+// ParseXHTML parses an XHTML document served as XML into a Document. Unlike
+// ParseDocument it does not repair markup: the document must be well-formed.
+// Elements are resolved by namespace, so XHTML elements look exactly like parsed
+// HTML to analyzers, while embedded SVG and MathML keep their own namespace.
+// content must already be UTF-8; the encoding in its XML declaration is ignored.
+func ParseXHTML(content string) (*Document, error) {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+
+	root := &html.Node{Type: html.DocumentNode}
+	parent := root
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformedXML, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := xmlElement(t)
+			parent.AppendChild(node)
+			parent = node
+		case xml.EndElement:
+			parent = parent.Parent
+		case xml.CharData:
+			if parent != root {
+				parent.AppendChild(&html.Node{Type: html.TextNode, Data: string(t)})
+			}
+		case xml.Comment:
+			parent.AppendChild(&html.Node{Type: html.CommentNode, Data: string(t)})
+		case xml.Directive:
+			if doctype := xmlDoctype(t); doctype != nil {
+				root.AppendChild(doctype)
+			}
+		}
+	}
+
+	if root.FirstChild == nil {
+		return nil, fmt.Errorf("%w: no root element", ErrMalformedXML)
+	}
+	return &Document{Root: root, XML: true}, nil
+}
+
+// xmlElement converts an XML start tag into an element node. XHTML elements and
+// elements without a namespace get the empty namespace, as html.Parse gives them.
+func xmlElement(t xml.StartElement) *html.Node {
+	node := &html.Node{Type: html.ElementNode, Data: t.Name.Local}
+	switch t.Name.Space {
+	case "", xhtmlNamespace:
+		node.DataAtom = atom.Lookup([]byte(t.Name.Local))
+	case svgNamespace:
+		node.Namespace = "svg"
+	case mathNamespace:
+		node.Namespace = "math"
+	default:
+		node.Namespace = t.Name.Space
+	}
+
+	for _, a := range t.Attr {
+		attribute := html.Attribute{Key: a.Name.Local, Val: a.Value}
+		switch a.Name.Space {
+		case "":
+		case "xmlns":
+			attribute.Key = "xmlns:" + a.Name.Local
+		case xmlNamespace:
+			attribute.Key = "xml:" + a.Name.Local
+		case xlinkNamespace:
+			attribute.Namespace = "xlink"
+		default:
+			attribute.Namespace = a.Name.Space
+		}
+		node.Attr = append(node.Attr, attribute)
+	}
+	return node
+}
+
+// xmlDoctype converts a <!DOCTYPE ...> directive into a DOCTYPE node, or returns
+// nil for other directives. The HTML tokenizer extracts the public and system IDs.
+func xmlDoctype(directive xml.Directive) *html.Node {
+	if !strings.HasPrefix(strings.ToUpper(string(directive)), "DOCTYPE") {
+		return nil
+	}
+
+	doc, err := html.Parse(strings.NewReader("<!" + string(directive) + ">"))
+	if err != nil {
+		return nil
+	}
+	for c := doc.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.DoctypeNode {
+			doc.RemoveChild(c)
+			return c
+		}
+	}
+	return nil
+}
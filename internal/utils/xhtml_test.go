@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+func TestParseXHTML(t *testing.T) {
+	doc, err := ParseXHTML(`<?xml version="1.0"?>
+<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Strict//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd">
+<!-- generated -->
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:xlink="http://www.w3.org/1999/xlink" xml:lang="en">
+<head><title>Fish &amp; Chips&nbsp;&#8212; menu</title></head>
+<body>
+	<h1 id="top">Menu</h1>
+	<svg xmlns="http://www.w3.org/2000/svg"><a xlink:href="#top"><text>Top</text></a></svg>
+	<math xmlns="http://www.w3.org/1998/Math/MathML"><mi>x</mi></math>
+</body>
+</html>`)
+	require.NoError(t, err)
+
+	assert.True(t, doc.XML)
+	assert.Equal(t, "Fish & Chips — menu", ExtractTitle(doc))
+
+	doctype := doc.Doctype()
+	require.NotNil(t, doctype)
+	assert.Equal(t, "html", doctype.Data)
+	assert.Equal(t, []html.Attribute{
+		{Key: "public", Val: "-//W3C//DTD XHTML 1.0 Strict//EN"},
+		{Key: "system", Val: "http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd"},
+	}, doctype.Attr)
+
+	root := doc.Find("html")
+	require.NotNil(t, root)
+	assert.Equal(t, "", root.Namespace)
+	assert.Equal(t, atom.Html, root.DataAtom)
+	assert.Equal(t, []html.Attribute{
+		{Key: "xmlns", Val: "http://www.w3.org/1999/xhtml"},
+		{Key: "xmlns:xlink", Val: "http://www.w3.org/1999/xlink"},
+		{Key: "xml:lang", Val: "en"},
+	}, root.Attr)
+
+	heading := doc.Find("h1")
+	require.NotNil(t, heading)
+	assert.Equal(t, "Menu", nodeText(heading))
+
+	svgLink := doc.FindAll("a")[0]
+	assert.Equal(t, "svg", svgLink.Namespace)
+	assert.Equal(t, []html.Attribute{{Namespace: "xlink", Key: "href", Val: "#top"}}, svgLink.Attr)
+	assert.Equal(t, "math", doc.Find("mi").Namespace)
+}
+
+func TestParseXHTML_Malformed(t *testing.T) {
+	for _, content := range []string{
+		`<html><body><p>Unclosed</body></html>`,
+		`<html><body>&bogus;</body></html>`,
+		`<?xml version="1.0"?>`,
+	} {
+		_, err := ParseXHTML(content)
+		assert.ErrorIs(t, err, ErrMalformedXML, content)
+	}
+}
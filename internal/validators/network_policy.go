@@ -0,0 +1,281 @@
+package validators
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/uikee/web-analyzer-service/config"
+)
+
+var (
+	// ErrDisallowedHost indicates that the URL host is denied by the network policy
+	ErrDisallowedHost = errors.New("URL host is not allowed")
+
+	// ErrDisallowedAddress indicates that the URL host resolves to a denied address
+	ErrDisallowedAddress = errors.New("URL resolves to a disallowed address")
+
+	// ErrHostResolution indicates that the URL host could not be resolved
+	ErrHostResolution = errors.New("URL host could not be resolved")
+
+	// ErrInvalidCIDR indicates that a configured CIDR range could not be parsed
+	ErrInvalidCIDR = errors.New("invalid CIDR range")
+)
+
+// blockedCIDRs are never reachable unless explicitly allowed: loopback, private,
+// link-local (including cloud metadata), shared, multicast and reserved ranges
+var blockedCIDRs = []string{
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.0.2.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"64:ff9b::/96",
+	"100::/64",
+	"2001:db8::/32",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+}
+
+// blockedHosts are metadata and local names rejected before any DNS lookup
+var blockedHosts = []string{
+	"localhost",
+	"*.localhost",
+	"metadata",
+	"metadata.google.internal",
+}
+
+// Resolver looks up the addresses of a host
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// NetworkPolicy decides which hosts and addresses the service may connect to.
+// It protects against SSRF by rejecting internal addresses, both when a URL is
+// validated and whenever a connection is dialed.
+type NetworkPolicy struct {
+	blocked    []*net.IPNet
+	allowCIDRs []*net.IPNet
+	denyCIDRs  []*net.IPNet
+	allowHosts []string
+	denyHosts  []string
+	resolver   Resolver
+}
+
+// NewNetworkPolicy creates a NetworkPolicy from configuration.
+//
+//   - AllowCIDRs are exempt from the built-in blocked ranges (e.g. a staging network)
+//   - DenyCIDRs are rejected in addition to the built-in blocked ranges
+//   - AllowHosts, when set, restricts requests to matching host names
+//   - DenyHosts are always rejected
+//
+// Host patterns match a host exactly or, with a "*." prefix, any subdomain.
+func NewNetworkPolicy(cfg config.SSRFConfig) (*NetworkPolicy, error) {
+	blocked, err := parseCIDRs(blockedCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	allowCIDRs, err := parseCIDRs(cfg.AllowCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	denyCIDRs, err := parseCIDRs(cfg.DenyCIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NetworkPolicy{
+		blocked:    blocked,
+		allowCIDRs: allowCIDRs,
+		denyCIDRs:  denyCIDRs,
+		allowHosts: normalizePatterns(cfg.AllowHosts),
+		denyHosts:  normalizePatterns(append(append([]string(nil), blockedHosts...), cfg.DenyHosts...)),
+		resolver:   net.DefaultResolver,
+	}, nil
+}
+
+// WithResolver returns a copy of the policy that resolves host names with r
+func (p *NetworkPolicy) WithResolver(r Resolver) *NetworkPolicy {
+	clone := *p
+	clone.resolver = r
+	return &clone
+}
+
+// CheckURL checks the URL's host name and every address it resolves to
+func (p *NetworkPolicy) CheckURL(ctx context.Context, target *url.URL) error {
+	if err := p.CheckHost(target.Hostname()); err != nil {
+		return err
+	}
+	_, err := p.ResolveAllowed(ctx, target.Hostname())
+	return err
+}
+
+// CheckHost checks a host name against the allow and deny patterns. IP literals
+// are only subject to address checks.
+func (p *NetworkPolicy) CheckHost(host string) error {
+	host = normalizeHost(host)
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+
+	if matchesAny(host, p.denyHosts) {
+		return fmt.Errorf("%w: %s", ErrDisallowedHost, host)
+	}
+	if len(p.allowHosts) > 0 && !matchesAny(host, p.allowHosts) {
+		return fmt.Errorf("%w: %s", ErrDisallowedHost, host)
+	}
+	return nil
+}
+
+// CheckIP checks a single address against the allowed and blocked ranges
+func (p *NetworkPolicy) CheckIP(ip net.IP) error {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+
+	if containsIP(p.denyCIDRs, ip) {
+		return fmt.Errorf("%w: %s", ErrDisallowedAddress, ip)
+	}
+	if containsIP(p.allowCIDRs, ip) {
+		return nil
+	}
+	if containsIP(p.blocked, ip) {
+		return fmt.Errorf("%w: %s", ErrDisallowedAddress, ip)
+	}
+	return nil
+}
+
+// ResolveAllowed resolves host and returns its addresses, failing if any of them is
+// disallowed. Rejecting mixed answers prevents an attacker from slipping an internal
+// address in next to a public one.
+func (p *NetworkPolicy) ResolveAllowed(ctx context.Context, host string) ([]net.IP, error) {
+	host = normalizeHost(host)
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		addrs, err := p.resolver.LookupIPAddr(ctx, host)
+		if err != nil || len(addrs) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrHostResolution, host)
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	for _, ip := range ips {
+		if err := p.CheckIP(ip); err != nil {
+			return nil, err
+		}
+	}
+	return ips, nil
+}
+
+// DialContext wraps dial so that every connection goes to an address that was
+// checked at dial time. This covers redirect hops, link checks and DNS answers
+// that change between validation and connection (DNS rebinding).
+func (p *NetworkPolicy) DialContext(dial func(ctx context.Context, network, address string) (net.Conn, error)) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+
+		ips, err := p.ResolveAllowed(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var dialErr error
+		for _, ip := range ips {
+			conn, err := dial(ctx, network, net.JoinHostPort(ip.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			dialErr = err
+		}
+		return nil, dialErr
+	}
+}
+
+// parseCIDRs parses CIDR ranges; bare IP addresses are treated as single-address ranges
+func parseCIDRs(values []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			if ip := net.ParseIP(value); ip != nil && ip.To4() != nil {
+				value += "/32"
+			} else {
+				value += "/128"
+			}
+		}
+
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidCIDR, value)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// containsIP reports whether any of the ranges contains ip
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeHost lower-cases a host name and strips IPv6 brackets and a trailing dot
+func normalizeHost(host string) string {
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// normalizePatterns prepares host patterns for matching
+func normalizePatterns(patterns []string) []string {
+	var normalized []string
+	for _, pattern := range patterns {
+		if pattern = normalizeHost(strings.TrimSpace(pattern)); pattern != "" {
+			normalized = append(normalized, pattern)
+		}
+	}
+	return normalized
+}
+
+// matchesAny reports whether host matches one of the patterns
+func matchesAny(host string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package validators
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uikee/web-analyzer-service/config"
+)
+
+// stubResolver answers lookups from a fixed host-to-addresses table
+type stubResolver map[string][]string
+
+func (r stubResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	addrs, ok := r[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	var ips []net.IPAddr
+	for _, addr := range addrs {
+		ips = append(ips, net.IPAddr{IP: net.ParseIP(addr)})
+	}
+	return ips, nil
+}
+
+func newTestPolicy(t *testing.T, cfg config.SSRFConfig, resolver stubResolver) *NetworkPolicy {
+	policy, err := NewNetworkPolicy(cfg)
+	require.NoError(t, err)
+	return policy.WithResolver(resolver)
+}
+
+func TestNetworkPolicy_CheckIP(t *testing.T) {
+	policy := newTestPolicy(t, config.SSRFConfig{}, nil)
+
+	blocked := []string{
+		"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254",
+		"100.100.100.200", "0.0.0.0", "224.0.0.1", "::1", "fe80::1", "fd00:ec2::254",
+		"::ffff:127.0.0.1",
+	}
+	for _, addr := range blocked {
+		assert.ErrorIs(t, policy.CheckIP(net.ParseIP(addr)), ErrDisallowedAddress, addr)
+	}
+
+	for _, addr := range []string{"93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"} {
+		assert.NoError(t, policy.CheckIP(net.ParseIP(addr)), addr)
+	}
+}
+
+func TestNetworkPolicy_AllowAndDenyCIDRs(t *testing.T) {
+	policy := newTestPolicy(t, config.SSRFConfig{
+		AllowCIDRs: []string{"10.20.0.0/16"},
+		DenyCIDRs:  []string{"93.184.216.34", "10.20.30.0/24"},
+	}, nil)
+
+	assert.NoError(t, policy.CheckIP(net.ParseIP("10.20.1.1")), "Allowed range is exempt from the built-in blocks")
+	assert.ErrorIs(t, policy.CheckIP(net.ParseIP("10.20.30.1")), ErrDisallowedAddress, "Deny takes precedence over allow")
+	assert.ErrorIs(t, policy.CheckIP(net.ParseIP("93.184.216.34")), ErrDisallowedAddress, "A bare IP denies a single address")
+}
+
+func TestNewNetworkPolicy_InvalidCIDR(t *testing.T) {
+	_, err := NewNetworkPolicy(config.SSRFConfig{DenyCIDRs: []string{"10.0.0.0/99"}})
+
+	assert.ErrorIs(t, err, ErrInvalidCIDR)
+}
+
+func TestNetworkPolicy_CheckHost(t *testing.T) {
+	policy := newTestPolicy(t, config.SSRFConfig{DenyHosts: []string{"*.corp.example.com"}}, nil)
+
+	assert.ErrorIs(t, policy.CheckHost("localhost"), ErrDisallowedHost)
+	assert.ErrorIs(t, policy.CheckHost("Metadata.Google.Internal."), ErrDisallowedHost)
+	assert.ErrorIs(t, policy.CheckHost("wiki.corp.example.com"), ErrDisallowedHost)
+	assert.NoError(t, policy.CheckHost("corp.example.com"), "A wildcard only matches subdomains")
+	assert.NoError(t, policy.CheckHost("127.0.0.1"), "IP literals are checked by address")
+
+	restricted := newTestPolicy(t, config.SSRFConfig{AllowHosts: []string{"example.com", "*.example.org"}}, nil)
+
+	assert.NoError(t, restricted.CheckHost("example.com"))
+	assert.NoError(t, restricted.CheckHost("www.example.org"))
+	assert.ErrorIs(t, restricted.CheckHost("example.net"), ErrDisallowedHost)
+}
+
+func TestNetworkPolicy_CheckURL(t *testing.T) {
+	policy := newTestPolicy(t, config.SSRFConfig{}, stubResolver{
+		"public.example.com": {"93.184.216.34"},
+		"mixed.example.com":  {"93.184.216.34", "10.0.0.5"},
+	})
+
+	check := func(raw string) error {
+		target, err := url.Parse(raw)
+		require.NoError(t, err)
+		return policy.CheckURL(context.Background(), target)
+	}
+
+	assert.NoError(t, check("http://public.example.com/"))
+	assert.ErrorIs(t, check("http://mixed.example.com/"), ErrDisallowedAddress, "Any internal answer rejects the host")
+	assert.ErrorIs(t, check("http://[::1]:8080/"), ErrDisallowedAddress)
+	assert.ErrorIs(t, check("http://unknown.example.com/"), ErrHostResolution)
+}
+
+func TestNetworkPolicy_DialContextChecksResolvedAddress(t *testing.T) {
+	policy := newTestPolicy(t, config.SSRFConfig{}, stubResolver{
+		"public.example.com": {"93.184.216.34"},
+		"rebind.example.com": {"127.0.0.1"},
+	})
+
+	var dialed []string
+	dial := policy.DialContext(func(ctx context.Context, network, address string) (net.Conn, error) {
+		dialed = append(dialed, address)
+		return nil, errors.New("not connecting in tests")
+	})
+
+	_, err := dial(context.Background(), "tcp", "rebind.example.com:80")
+	assert.ErrorIs(t, err, ErrDisallowedAddress)
+	assert.Empty(t, dialed, "A disallowed address must never be dialed")
+
+	_, _ = dial(context.Background(), "tcp", "public.example.com:443")
+	assert.Equal(t, []string{"93.184.216.34:443"}, dialed, "The checked address is dialed, not the host name")
+}
@@ -1,9 +1,10 @@
 package validators
 
 import (
+	"context"
 	"errors"
-	"net/http"
 	"net/url"
+	"time"
 )
 
 // URLValidator defines an interface for URL validation
@@ -12,11 +13,17 @@ type URLValidator interface {
 }
 
 // DefaultURLValidator implements URL validation logic
-type DefaultURLValidator struct{}
+type DefaultURLValidator struct {
+	policy *NetworkPolicy
+}
+
+// resolveTimeout bounds the DNS lookup done while validating a URL
+const resolveTimeout = 5 * time.Second
 
-// NewURLValidator creates a new instance of DefaultURLValidator
-func NewURLValidator() URLValidator {
-	return &DefaultURLValidator{}
+// NewURLValidator creates a new instance of DefaultURLValidator. A nil policy
+// disables the network checks and only validates syntax.
+func NewURLValidator(policy *NetworkPolicy) URLValidator {
+	return &DefaultURLValidator{policy: policy}
 }
 
 var (
@@ -26,29 +33,38 @@ var (
 	// ErrInvalidURLFormat indicates that the URL is not in a valid format
 	ErrInvalidURLFormat = errors.New("invalid URL format, please provide a valid URL")
 
-	// ErrURLNotReachable indicates that the URL could not be reached
-	ErrURLNotReachable = errors.New("URL is not reachable, please provide a valid URL")
-
-	// ErrNon200StatusCode indicates that the URL returned a non-200 HTTP status code
-	ErrNon200StatusCode = errors.New("URL returned non-200 status")
+	// ErrUnsupportedScheme indicates that the URL scheme is not http or https
+	ErrUnsupportedScheme = errors.New("unsupported URL scheme, only http and https are allowed")
 )
 
-// Validate checks if the given URL is valid and reachable
+// Validate checks the syntax of the given URL and whether it may be analyzed.
+// With a network policy the host is resolved and every address is checked, but
+// reachability is not; the service fetches the page exactly once.
 func (v *DefaultURLValidator) Validate(targetURL string) error {
-	_, err := url.ParseRequestURI(targetURL)
+	parsedURL, err := ParseHTTPURL(targetURL)
 	if err != nil {
-		return ErrInvalidURLFormat
+		return err
 	}
 
-	resp, err := http.Get(targetURL)
-	if err != nil {
-		return ErrURLNotReachable
+	if v.policy != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+		defer cancel()
+		return v.policy.CheckURL(ctx, parsedURL)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return ErrNon200StatusCode
+	return nil
+}
+
+// ParseHTTPURL parses an absolute http or https URL without checking its host
+// against any network policy
+func ParseHTTPURL(targetURL string) (*url.URL, error) {
+	parsedURL, err := url.ParseRequestURI(targetURL)
+	if err != nil || parsedURL.Host == "" {
+		return nil, ErrInvalidURLFormat
 	}
 
-	return nil
-}
\ No newline at end of file
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return nil, ErrUnsupportedScheme
+	}
+	return parsedURL, nil
+}